@@ -0,0 +1,194 @@
+package api
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/client"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 归档的压缩方式
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+type ArchiveOptions struct {
+	// Path 相对于归档目录的文件路径，为空时自动生成
+	Path        string
+	Compression string
+	// Hash 计算写入文件的 sha256
+	Hash      bool
+	Overwrite bool
+}
+
+// ResolveArchivePath 把文件路径限制在允许的归档目录内，防止通过 .. 或符号链接写到目录之外
+func ResolveArchivePath(dir, name string) (string, error) {
+	root, err := archiveRoot(dir)
+	if err != nil {
+		return "", err
+	}
+	target := name
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(root, target)
+	}
+	target = filepath.Clean(target)
+	// 父目录可能是符号链接，解析后再判断
+	parent, err := filepath.EvalSymlinks(filepath.Dir(target))
+	if err != nil {
+		return "", fmt.Errorf("invalid archive path %q: %v", name, err)
+	}
+	target = filepath.Join(parent, filepath.Base(target))
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive path %q is outside the allowed directory %s", name, root)
+	}
+	return target, nil
+}
+
+// ResolveArchiveDir 把已存在的目录限制在归档目录内，目录本身也可以是归档目录，用于读取构建上下文
+func ResolveArchiveDir(dir, name string) (string, error) {
+	root, err := archiveRoot(dir)
+	if err != nil {
+		return "", err
+	}
+	target := name
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(root, target)
+	}
+	target, err = filepath.EvalSymlinks(filepath.Clean(target))
+	if err != nil {
+		return "", fmt.Errorf("invalid directory %q: %v", name, err)
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("directory %q is outside the allowed directory %s", name, root)
+	}
+	return target, nil
+}
+
+// archiveRoot 返回解析符号链接后的归档目录，不存在时创建
+func archiveRoot(dir string) (string, error) {
+	if dir == "" {
+		return "", errors.New("archive directory is not configured, start the server with --archive-dir or DOCKER_ARCHIVE_DIR")
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return "", fmt.Errorf("create archive directory failed: %v", err)
+	}
+	return filepath.EvalSymlinks(root)
+}
+
+// WriteArchive 把 reader 的内容写入归档目录，先写临时文件再重命名，避免留下不完整的文件
+func WriteArchive(dir string, reader io.Reader, opts ArchiveOptions) (*resp.Archive, error) {
+	if opts.Compression == "" {
+		opts.Compression = CompressionNone
+	}
+	if opts.Compression != CompressionNone && opts.Compression != CompressionGzip {
+		return nil, fmt.Errorf("unsupported compression %q, expected none or gzip", opts.Compression)
+	}
+	target, err := ResolveArchivePath(dir, opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(target); err == nil && !opts.Overwrite {
+		return nil, fmt.Errorf("archive %s already exists", target)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	var digest hash.Hash
+	var out io.Writer = tmp
+	if opts.Hash {
+		digest = sha256.New()
+		out = io.MultiWriter(tmp, digest)
+	}
+	counter := &countingWriter{w: out}
+	var copyErr error
+	if opts.Compression == CompressionGzip {
+		gz := gzip.NewWriter(counter)
+		_, copyErr = io.Copy(gz, reader)
+		if err := gz.Close(); copyErr == nil {
+			copyErr = err
+		}
+	} else {
+		_, copyErr = io.Copy(counter, reader)
+	}
+	if err := tmp.Close(); copyErr == nil {
+		copyErr = err
+	}
+	if copyErr != nil {
+		return nil, fmt.Errorf("write archive %s failed: %v", target, copyErr)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return nil, err
+	}
+	archive := &resp.Archive{Path: target, Size: counter.n, Compression: opts.Compression}
+	if digest != nil {
+		archive.Digest = "sha256:" + hex.EncodeToString(digest.Sum(nil))
+	}
+	return archive, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// archiveName 默认文件名：名称-时间.tar[.gz]
+func archiveName(name, compression string) string {
+	name = volumeNameInvalid.ReplaceAllString(strings.TrimPrefix(name, "/"), "_")
+	file := fmt.Sprintf("%s-%s.tar", name, time.Now().Format("20060102-150405"))
+	if compression == CompressionGzip {
+		file += ".gz"
+	}
+	return file
+}
+
+// ExportContainer 把容器的完整文件系统导出为 tar 文件，用于取证分析
+func ExportContainer(ctx context.Context, cli *client.Client, containerID, dir string, opts ArchiveOptions) (*resp.Archive, error) {
+	inspect, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		logs.ErrorWithFields("ContainerInspect failed", map[string]interface{}{"id": containerID, "error": err})
+		return nil, err
+	}
+	if opts.Path == "" {
+		opts.Path = archiveName(inspect.Name, opts.Compression)
+	}
+	reader, err := cli.ContainerExport(ctx, inspect.ID)
+	if err != nil {
+		logs.ErrorWithFields("ContainerExport failed", map[string]interface{}{"id": inspect.ID, "error": err})
+		return nil, err
+	}
+	defer reader.Close()
+	archive, err := WriteArchive(dir, reader, opts)
+	if err != nil {
+		logs.ErrorWithFields("WriteArchive failed", map[string]interface{}{"id": inspect.ID, "error": err})
+		return nil, err
+	}
+	logs.InfoWithFields("ContainerExport success", map[string]interface{}{"id": inspect.ID, "path": archive.Path, "size": archive.Size})
+	return archive, nil
+}
@@ -0,0 +1,434 @@
+package api
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/rand"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// stepOutputLines 成功步骤保留的输出行数，失败步骤保留 failedStepOutputLines 行
+	stepOutputLines       = 10
+	failedStepOutputLines = 200
+)
+
+var buildStepPattern = regexp.MustCompile(`^Step (\d+)/(\d+) : (.*)$`)
+
+type BuildOptions struct {
+	// ContextDir 服务所在主机上的构建上下文目录，必须位于归档目录内，相对路径相对于归档目录
+	ContextDir string `json:"contextDir,omitempty"`
+	// Dockerfile 上下文中 Dockerfile 的相对路径，默认 Dockerfile
+	Dockerfile string `json:"dockerfile,omitempty"`
+	// DockerfileContent 内联的 Dockerfile 内容，优先于 Dockerfile
+	DockerfileContent string            `json:"dockerfileContent,omitempty"`
+	BuildArgs         map[string]string `json:"buildArgs,omitempty"`
+	Target            string            `json:"target,omitempty"`
+	Tags              []string          `json:"tags,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	NoCache           bool              `json:"noCache,omitempty"`
+	Pull              bool              `json:"pull,omitempty"`
+	Platform          string            `json:"platform,omitempty"`
+}
+
+// BuildImage 打包构建上下文并调用 ImageBuild，构建失败记录在返回结果的 Error 和 FailedStep 中
+func BuildImage(ctx context.Context, cli *client.Client, archiveDir string, opts BuildOptions) (*resp.ImageBuild, error) {
+	if opts.ContextDir == "" && opts.DockerfileContent == "" {
+		return nil, errors.New("contextDir or dockerfileContent is required")
+	}
+	dockerfile := opts.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	extra := make(map[string][]byte)
+	if opts.DockerfileContent != "" {
+		// 内联的 Dockerfile 使用随机文件名加入上下文，避免覆盖上下文中已有的文件
+		suffix := make([]byte, 8)
+		_, _ = rand.Read(suffix)
+		dockerfile = ".dockerfile." + hex.EncodeToString(suffix)
+		extra[dockerfile] = []byte(opts.DockerfileContent)
+	}
+
+	var buildContext io.ReadCloser
+	content := opts.DockerfileContent
+	if opts.ContextDir != "" {
+		contextDir, err := ResolveArchiveDir(archiveDir, opts.ContextDir)
+		if err != nil {
+			return nil, err
+		}
+		if content == "" {
+			content = readDockerfile(contextDir, dockerfile)
+		}
+		info, err := os.Stat(contextDir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid contextDir: %v", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("contextDir %s is not a directory", opts.ContextDir)
+		}
+		buildContext = tarBuildContext(contextDir, dockerfile, extra)
+	} else {
+		buildContext = tarBuildContext("", dockerfile, extra)
+	}
+	defer buildContext.Close()
+
+	buildArgs := make(map[string]*string, len(opts.BuildArgs))
+	for k, v := range opts.BuildArgs {
+		value := v
+		buildArgs[k] = &value
+	}
+	// 只查询 Dockerfile 引用的仓库的凭据，无法确定时才查询全部仓库
+	authConfigs := Credentials.All()
+	if hosts, ok := buildRegistries(content, opts.BuildArgs); ok {
+		authConfigs = Credentials.ForHosts(hosts)
+	}
+	logs.InfoWithFields("Start building image", map[string]interface{}{"context": opts.ContextDir, "dockerfile": dockerfile, "tags": opts.Tags})
+	build, err := cli.ImageBuild(ctx, buildContext, types.ImageBuildOptions{
+		Tags:        opts.Tags,
+		Dockerfile:  filepath.ToSlash(dockerfile),
+		BuildArgs:   buildArgs,
+		Target:      opts.Target,
+		Labels:      opts.Labels,
+		NoCache:     opts.NoCache,
+		PullParent:  opts.Pull,
+		Platform:    opts.Platform,
+		AuthConfigs: authConfigs,
+		Remove:      true,
+		ForceRemove: true,
+		// 固定使用经典构建器：BuildKit 需要客户端通过 /session 提供 gRPC 会话来传输上下文和凭据，
+		// 输出也是 protobuf 编码的 trace 而不是文本；经典构建器直接接收 tar 上下文，输出带有 Step 信息，便于按步骤汇总。
+		// 代价是不支持 RUN --mount 等 BuildKit 专有语法
+		Version: types.BuilderV1,
+	})
+	if err != nil {
+		logs.ErrorWithFields("ImageBuild failed", map[string]interface{}{"context": opts.ContextDir, "error": err})
+		return nil, err
+	}
+	defer build.Body.Close()
+	result, err := summarizeBuild(build.Body)
+	if err != nil {
+		return nil, err
+	}
+	if result.Error == "" {
+		result.Tags = opts.Tags
+		logs.InfoWithFields("ImageBuild success", map[string]interface{}{"image": result.ImageID, "tags": opts.Tags})
+	} else {
+		logs.ErrorWithFields("ImageBuild finished with error", map[string]interface{}{"error": result.Error})
+	}
+	return result, nil
+}
+
+// summarizeBuild 解码构建输出流，按 Step 分组，失败时保留失败步骤的完整输出
+func summarizeBuild(stream io.Reader) (*resp.ImageBuild, error) {
+	result := &resp.ImageBuild{Steps: []resp.BuildStep{}}
+	var current *resp.BuildStep
+	var pending string
+	addLine := func(line string) {
+		line = strings.TrimRight(line, "\r")
+		if m := buildStepPattern.FindStringSubmatch(line); m != nil {
+			number, _ := strconv.Atoi(m[1])
+			total, _ := strconv.Atoi(m[2])
+			result.Steps = append(result.Steps, resp.BuildStep{Number: number, Total: total, Instruction: m[3]})
+			current = &result.Steps[len(result.Steps)-1]
+			return
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || current == nil {
+			return
+		}
+		if trimmed == "---> Using cache" {
+			current.Cached = true
+			return
+		}
+		current.Output = append(current.Output, line)
+	}
+
+	decoder := json.NewDecoder(stream)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("decode build output failed: %v", err)
+		}
+		if msg.Aux != nil {
+			var aux struct {
+				ID string `json:"ID"`
+			}
+			if json.Unmarshal(*msg.Aux, &aux) == nil && aux.ID != "" {
+				result.ImageID = aux.ID
+			}
+		}
+		if msg.Stream != "" {
+			// 一条消息可能只包含半行
+			pending += msg.Stream
+			for {
+				idx := strings.IndexByte(pending, '\n')
+				if idx < 0 {
+					break
+				}
+				addLine(pending[:idx])
+				pending = pending[idx+1:]
+			}
+		}
+		if msg.Error != nil {
+			result.Error = msg.Error.Message
+		} else if msg.ErrorMessage != "" {
+			result.Error = msg.ErrorMessage
+		}
+	}
+	if pending != "" {
+		addLine(pending)
+	}
+
+	for i := range result.Steps {
+		step := &result.Steps[i]
+		limit := stepOutputLines
+		if result.Error != "" && i == len(result.Steps)-1 {
+			limit = failedStepOutputLines
+		}
+		if len(step.Output) > limit {
+			step.Output = append([]string{fmt.Sprintf("... %d lines omitted", len(step.Output)-limit)}, step.Output[len(step.Output)-limit:]...)
+		}
+	}
+	if result.Error != "" && len(result.Steps) > 0 {
+		failed := result.Steps[len(result.Steps)-1]
+		result.FailedStep = &failed
+	}
+	return result, nil
+}
+
+// readDockerfile 读取上下文中的 Dockerfile，不存在或位于上下文之外时返回空字符串
+func readDockerfile(contextDir, dockerfile string) string {
+	path, err := filepath.EvalSymlinks(filepath.Join(contextDir, dockerfile))
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(contextDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// buildRegistries 找出 FROM 和 COPY --from 引用的镜像所在仓库，ok 为 false 表示存在无法解析的引用
+func buildRegistries(dockerfile string, buildArgs map[string]string) (hosts []string, ok bool) {
+	if dockerfile == "" {
+		return nil, false
+	}
+	defaults := make(map[string]string)
+	stages := make(map[string]bool)
+	seen := make(map[string]bool)
+	ok = true
+	add := func(ref string) {
+		resolved := true
+		ref = os.Expand(ref, func(name string) string {
+			name, fallback, _ := strings.Cut(name, ":-")
+			if v, found := buildArgs[name]; found {
+				return v
+			}
+			if v, found := defaults[name]; found {
+				return v
+			}
+			if fallback == "" {
+				resolved = false
+			}
+			return fallback
+		})
+		if _, err := strconv.Atoi(ref); err == nil || stages[strings.ToLower(ref)] || ref == "scratch" {
+			return
+		}
+		host, err := ImageRegistryHost(ref)
+		if !resolved || err != nil {
+			ok = false
+			return
+		}
+		if !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	fromSeen := false
+	for _, line := range dockerfileInstructions(dockerfile) {
+		fields := strings.Fields(line)
+		switch strings.ToUpper(fields[0]) {
+		case "ARG":
+			// 只有第一个 FROM 之前的 ARG 能用在 FROM 中
+			if fromSeen {
+				continue
+			}
+			for _, arg := range fields[1:] {
+				name, value, hasValue := strings.Cut(arg, "=")
+				if hasValue {
+					defaults[name] = strings.Trim(value, `"'`)
+				}
+			}
+		case "FROM":
+			fromSeen = true
+			args := fields[1:]
+			for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+				args = args[1:]
+			}
+			if len(args) == 0 {
+				continue
+			}
+			add(args[0])
+			if len(args) >= 3 && strings.EqualFold(args[1], "AS") {
+				stages[strings.ToLower(args[2])] = true
+			}
+		case "COPY":
+			for _, flag := range fields[1:] {
+				if from, found := strings.CutPrefix(flag, "--from="); found {
+					add(from)
+				}
+			}
+		}
+	}
+	return hosts, ok
+}
+
+// dockerfileInstructions 合并续行，去掉空行和注释，每条指令一行
+func dockerfileInstructions(dockerfile string) []string {
+	var instructions []string
+	var current string
+	for _, line := range strings.Split(dockerfile, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasSuffix(trimmed, "\\") {
+			current += strings.TrimSuffix(trimmed, "\\") + " "
+			continue
+		}
+		instructions = append(instructions, current+trimmed)
+		current = ""
+	}
+	if strings.TrimSpace(current) != "" {
+		instructions = append(instructions, current)
+	}
+	return instructions
+}
+
+// tarBuildContext 按 .dockerignore 打包构建上下文，extra 中的文件额外加入归档
+func tarBuildContext(dir, dockerfile string, extra map[string][]byte) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(writer)
+		err := writeBuildContext(tw, dir, dockerfile)
+		if err == nil {
+			now := time.Now()
+			for name, content := range extra {
+				if err = tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(name), Mode: 0o644, Size: int64(len(content)), ModTime: now, Typeflag: tar.TypeReg}); err != nil {
+					break
+				}
+				if _, err = tw.Write(content); err != nil {
+					break
+				}
+			}
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+	return reader
+}
+
+func writeBuildContext(tw *tar.Writer, dir, dockerfile string) error {
+	if dir == "" {
+		return nil
+	}
+	var excludes []string
+	if f, err := os.Open(filepath.Join(dir, ".dockerignore")); err == nil {
+		excludes, err = ignorefile.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("read .dockerignore failed: %v", err)
+		}
+	}
+	pm, err := patternmatcher.New(excludes)
+	if err != nil {
+		return fmt.Errorf("invalid .dockerignore: %v", err)
+	}
+	// Dockerfile 和 .dockerignore 即使被忽略也需要发送给守护进程
+	keep := map[string]bool{filepath.Clean(dockerfile): true, ".dockerignore": true}
+
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if !keep[rel] {
+			ignored, err := pm.MatchesOrParentMatches(filepath.ToSlash(rel))
+			if err != nil {
+				return err
+			}
+			if ignored {
+				// 没有 ! 例外规则时可以跳过整个目录
+				if d.IsDir() && !pm.Exclusions() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		// 构建结果不应依赖主机上的用户
+		header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 克隆卷的处理方式
+const (
+	CloneVolumesShare = "share"
+	CloneVolumesCopy  = "copy"
+)
+
+// 卷名中不允许出现的字符
+var volumeNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+type CloneOptions struct {
+	// Name 克隆出的容器名称
+	Name string
+	// RemapPorts 把已发布的端口改为由 Docker 分配空闲的主机端口
+	RemapPorts bool
+	// Volumes share 与源容器共用卷，copy 复制到新的命名卷
+	Volumes string
+	// Snapshot 先把源容器当前的文件系统提交为镜像，克隆基于该镜像创建
+	Snapshot bool
+	// Start 创建后启动
+	Start bool
+}
+
+// CloneContainer 直接复制源容器的 Config 和 HostConfig 创建一个同样配置的容器，用于复现问题。
+// 失败时删除已创建的容器和卷，同时返回记录了清理情况的 result 和 error
+func CloneContainer(ctx context.Context, cli *client.Client, containerID string, opts CloneOptions) (*resp.ContainerClone, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("clone name is required")
+	}
+	if opts.Volumes == "" {
+		opts.Volumes = CloneVolumesShare
+	}
+	if opts.Volumes != CloneVolumesShare && opts.Volumes != CloneVolumesCopy {
+		return nil, fmt.Errorf("unsupported volumes mode %q, expected share or copy", opts.Volumes)
+	}
+	replica, inspect, err := InspectReplica(ctx, cli, containerID)
+	if err != nil {
+		return nil, err
+	}
+	result := &resp.ContainerClone{
+		Name:   opts.Name,
+		Source: replica.Name,
+	}
+	replica.Name = opts.Name
+	// 克隆不能复用源容器的自动删除、固定IP、MAC和网络别名，否则会冲突或抢走源容器的流量
+	replica.HostConfig.AutoRemove = false
+	replica.HostConfig.ContainerIDFile = ""
+	// 旧版本 API 把用户指定的 MAC 保存在 Config 中
+	replica.Config.MacAddress = ""
+	for _, n := range replica.Networks {
+		n.Endpoint.IPAMConfig = nil
+		n.Endpoint.Aliases = nil
+		n.Endpoint.MacAddress = ""
+	}
+	if opts.RemapPorts {
+		for port, bindings := range replica.HostConfig.PortBindings {
+			for i := range bindings {
+				// 主机端口为空时由 Docker 分配空闲端口
+				bindings[i].HostPort = ""
+			}
+			replica.HostConfig.PortBindings[port] = bindings
+		}
+	}
+
+	if opts.Snapshot {
+		ref := snapshotReference(opts.Name)
+		commit, err := ContainerCommit(ctx, cli, inspect.ID, CommitOptions{
+			Reference: ref,
+			Message:   fmt.Sprintf("snapshot of %s for clone %s", result.Source, opts.Name),
+			Pause:     true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		replica.Config.Image = commit.ID
+		result.Snapshot = ref
+	}
+	result.Image = replica.Config.Image
+
+	var createdVolumes []string
+	fail := func(err error) (*resp.ContainerClone, error) {
+		cleanupClone(ctx, cli, result, createdVolumes)
+		return result, err
+	}
+	copies := make([]container.MountPoint, 0)
+	for _, m := range inspect.Mounts {
+		if m.Type != mount.TypeVolume || m.Name == "" {
+			continue
+		}
+		cloned := resp.ClonedVolume{Destination: m.Destination, Source: m.Name, Volume: m.Name, Mode: CloneVolumesShare}
+		if opts.Volumes == CloneVolumesCopy {
+			// 同名卷已存在时 VolumeCreate 会直接返回它，复制和失败清理都会破坏不属于本次克隆的数据
+			name := cloneVolumeName(opts.Name, m.Destination)
+			if _, err := cli.VolumeInspect(ctx, name); err == nil {
+				return fail(fmt.Errorf("volume %s already exists, remove it or choose another clone name", name))
+			} else if !client.IsErrNotFound(err) {
+				logs.ErrorWithFields("VolumeInspect failed", map[string]interface{}{"volume": name, "error": err})
+				return fail(err)
+			}
+			created, err := cli.VolumeCreate(ctx, volume.CreateOptions{
+				Name:   name,
+				Driver: m.Driver,
+				Labels: map[string]string{"docker-mcp.clone-of": m.Name},
+			})
+			if err != nil {
+				logs.ErrorWithFields("VolumeCreate failed", map[string]interface{}{"source": m.Name, "error": err})
+				return fail(err)
+			}
+			createdVolumes = append(createdVolumes, created.Name)
+			replica.ReplaceVolume(m.Destination, created.Name)
+			cloned.Volume = created.Name
+			cloned.Mode = CloneVolumesCopy
+			copies = append(copies, m)
+		}
+		result.Volumes = append(result.Volumes, cloned)
+	}
+
+	create, err := CreateReplica(ctx, cli, replica)
+	if err != nil {
+		return fail(err)
+	}
+	result.ID = create.ID
+	result.Warnings = create.Warnings
+
+	if len(copies) > 0 {
+		if err := copyVolumes(ctx, cli, inspect, create.ID, copies); err != nil {
+			return fail(err)
+		}
+	}
+	if opts.Start {
+		if err := cli.ContainerStart(ctx, create.ID, container.StartOptions{}); err != nil {
+			logs.ErrorWithFields("ContainerStart failed", map[string]interface{}{"id": create.ID, "error": err})
+			return fail(err)
+		}
+		result.Started = true
+		// 端口重新映射后返回实际分配的主机端口
+		if started, err := cli.ContainerInspect(ctx, create.ID); err == nil && started.NetworkSettings != nil {
+			result.Ports = make(map[string][]string)
+			for port, bindings := range started.NetworkSettings.Ports {
+				for _, b := range bindings {
+					result.Ports[string(port)] = append(result.Ports[string(port)], b.HostIP+":"+b.HostPort)
+				}
+			}
+		}
+	}
+	logs.InfoWithFields("CloneContainer success", map[string]interface{}{"source": result.Source, "id": create.ID, "name": opts.Name})
+	return result, nil
+}
+
+// cleanupClone 删除克隆失败时留下的容器、复制出的卷和快照镜像，不使用请求的 ctx，取消后也要清理完
+func cleanupClone(ctx context.Context, cli *client.Client, result *resp.ContainerClone, volumes []string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+	if result.ID != "" {
+		if err := cli.ContainerRemove(ctx, result.ID, container.RemoveOptions{Force: true}); err != nil {
+			logs.ErrorWithFields("Remove failed clone failed", map[string]interface{}{"id": result.ID, "error": err})
+			result.Leftover = append(result.Leftover, "container "+result.ID)
+		} else {
+			result.CleanedUp = append(result.CleanedUp, "container "+result.ID)
+		}
+		result.Started = false
+	}
+	for _, name := range volumes {
+		if err := cli.VolumeRemove(ctx, name, true); err != nil {
+			logs.ErrorWithFields("Remove cloned volume failed", map[string]interface{}{"volume": name, "error": err})
+			result.Leftover = append(result.Leftover, "volume "+name)
+		} else {
+			result.CleanedUp = append(result.CleanedUp, "volume "+name)
+		}
+	}
+	if result.Snapshot != "" {
+		if _, err := cli.ImageRemove(ctx, result.Snapshot, image.RemoveOptions{}); err != nil {
+			logs.ErrorWithFields("Remove snapshot image failed", map[string]interface{}{"image": result.Snapshot, "error": err})
+			result.Leftover = append(result.Leftover, "image "+result.Snapshot)
+		} else {
+			result.CleanedUp = append(result.CleanedUp, "image "+result.Snapshot)
+		}
+	}
+}
+
+// copyVolumes 通过 CopyFromContainer/CopyToContainer 把源容器卷中的数据复制到克隆容器，复制期间暂停运行中的源容器
+func copyVolumes(ctx context.Context, cli *client.Client, source container.InspectResponse, targetID string, mounts []container.MountPoint) error {
+	if source.State != nil && source.State.Running && !source.State.Paused {
+		if err := cli.ContainerPause(ctx, source.ID); err == nil {
+			defer cli.ContainerUnpause(ctx, source.ID)
+		} else {
+			logs.WarnWithFields("Pause source container failed, copying live data", map[string]interface{}{"id": source.ID, "error": err})
+		}
+	}
+	for _, m := range mounts {
+		stream, _, err := cli.CopyFromContainer(ctx, source.ID, m.Destination)
+		if err != nil {
+			return fmt.Errorf("read volume %s at %s failed: %v", m.Name, m.Destination, err)
+		}
+		// 归档的根目录是目标路径的最后一级，解压到父目录即可还原
+		err = cli.CopyToContainer(ctx, targetID, path.Dir(m.Destination), stream, container.CopyToContainerOptions{CopyUIDGID: true})
+		stream.Close()
+		if err != nil {
+			return fmt.Errorf("copy volume %s to %s failed: %v", m.Name, m.Destination, err)
+		}
+		logs.InfoWithFields("Volume copied", map[string]interface{}{"volume": m.Name, "destination": m.Destination, "target": targetID})
+	}
+	return nil
+}
+
+func cloneVolumeName(cloneName, destination string) string {
+	suffix := volumeNameInvalid.ReplaceAllString(strings.Trim(destination, "/"), "_")
+	return volumeNameInvalid.ReplaceAllString(cloneName, "_") + "_" + suffix
+}
+
+// snapshotReference 快照镜像的引用，仓库名必须是小写
+func snapshotReference(cloneName string) string {
+	repo := strings.Trim(volumeNameInvalid.ReplaceAllString(strings.ToLower(cloneName), "-"), "-._")
+	if repo == "" {
+		repo = "clone"
+	}
+	return fmt.Sprintf("%s-snapshot:%d", repo, time.Now().Unix())
+}
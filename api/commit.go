@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-units"
+	"strings"
+)
+
+// commitInstructions 提交时 Docker 允许的 Dockerfile 指令
+var commitInstructions = map[string]bool{
+	"CMD":        true,
+	"ENTRYPOINT": true,
+	"ENV":        true,
+	"EXPOSE":     true,
+	"LABEL":      true,
+	"ONBUILD":    true,
+	"USER":       true,
+	"VOLUME":     true,
+	"WORKDIR":    true,
+}
+
+type CommitOptions struct {
+	// Reference 新镜像的 repository[:tag]，为空时生成悬空镜像
+	Reference string
+	Author    string
+	Message   string
+	// Changes Dockerfile 指令，例如 CMD ["sh"]、ENV DEBUG=1、LABEL stage=debug
+	Changes []string
+	// Pause 提交期间暂停容器，保证文件系统一致
+	Pause bool
+}
+
+// ContainerCommit 把容器当前的文件系统和配置提交为新镜像
+func ContainerCommit(ctx context.Context, cli *client.Client, containerID string, opts CommitOptions) (*resp.ContainerCommit, error) {
+	changes := make([]string, 0, len(opts.Changes))
+	for _, change := range opts.Changes {
+		change = strings.TrimSpace(change)
+		if change == "" {
+			continue
+		}
+		instruction, _, _ := strings.Cut(change, " ")
+		if !commitInstructions[strings.ToUpper(instruction)] {
+			return nil, fmt.Errorf("unsupported change %q, expected one of CMD, ENTRYPOINT, ENV, EXPOSE, LABEL, ONBUILD, USER, VOLUME, WORKDIR", change)
+		}
+		changes = append(changes, change)
+	}
+	commit, err := cli.ContainerCommit(ctx, containerID, container.CommitOptions{
+		Reference: opts.Reference,
+		Author:    opts.Author,
+		Comment:   opts.Message,
+		Changes:   changes,
+		Pause:     opts.Pause,
+	})
+	if err != nil {
+		logs.ErrorWithFields("ContainerCommit failed", map[string]interface{}{"id": containerID, "reference": opts.Reference, "error": err})
+		return nil, err
+	}
+	result := &resp.ContainerCommit{
+		ID:        commit.ID,
+		Container: containerID,
+		Reference: opts.Reference,
+	}
+	// 大小需要从镜像信息中获取
+	if inspect, err := cli.ImageInspect(ctx, commit.ID); err == nil {
+		result.Size = inspect.Size
+		result.SizeHuman = units.HumanSize(float64(inspect.Size))
+	} else {
+		logs.WarnWithFields("ImageInspect after commit failed", map[string]interface{}{"image": commit.ID, "error": err})
+	}
+	logs.InfoWithFields("ContainerCommit success", map[string]interface{}{"id": containerID, "image": commit.ID, "reference": opts.Reference})
+	return result, nil
+}
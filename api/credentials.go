@@ -0,0 +1,310 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"docker-mcp/cmd/logs"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DockerHubHost Docker Hub 在凭据存储中统一使用的主机名
+	DockerHubHost = "docker.io"
+	// DockerHubAddress Docker Hub 在 config.json 和凭据助手中使用的地址
+	DockerHubAddress = "https://index.docker.io/v1/"
+	// credentialHelperTimeout 调用 docker-credential-* 的超时时间
+	credentialHelperTimeout = 10 * time.Second
+	// credentialCacheTTL 从配置和凭据助手查到的结果（包括没有凭据）的缓存时间，过期后重新查找，
+	// 让 docker login/logout 和刷新的令牌在服务不重启的情况下生效
+	credentialCacheTTL = time.Minute
+)
+
+// Credentials 服务端的仓库凭据，按仓库主机保存，登录成功后写入，拉取、推送、构建时自动使用
+var Credentials = NewCredentialStore()
+
+// CredentialStore 查找顺序：登录保存的凭据、config.json 中 credHelpers 指定的助手、credsStore、auths
+type CredentialStore struct {
+	mu sync.RWMutex
+	// auths 登录保存的凭据，不过期
+	auths map[string]registry.AuthConfig
+	// cache 从配置和凭据助手查到的结果，config.json 修改后清空
+	cache         map[string]cachedCredential
+	config        *dockerConfig
+	configPath    string
+	configModTime time.Time
+	loaded        bool
+}
+
+type cachedCredential struct {
+	auth    registry.AuthConfig
+	found   bool
+	expires time.Time
+}
+
+// dockerConfig ~/.docker/config.json 中与认证相关的部分
+type dockerConfig struct {
+	Auths       map[string]dockerConfigAuth `json:"auths"`
+	CredsStore  string                      `json:"credsStore"`
+	CredHelpers map[string]string           `json:"credHelpers"`
+}
+
+type dockerConfigAuth struct {
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+func NewCredentialStore() *CredentialStore {
+	return &CredentialStore{auths: make(map[string]registry.AuthConfig), cache: make(map[string]cachedCredential)}
+}
+
+// Set 保存仓库凭据，已有的同一主机凭据会被覆盖
+func (s *CredentialStore) Set(auth registry.AuthConfig) {
+	host := RegistryHost(auth.ServerAddress)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auths[host] = auth
+	logs.InfoWithFields("Registry credential stored", map[string]interface{}{"registry": host, "username": auth.Username})
+}
+
+// Get 返回仓库主机的凭据，内存中没有时从 Docker 客户端配置和凭据助手中查找
+func (s *CredentialStore) Get(host string) (registry.AuthConfig, bool) {
+	host = RegistryHost(host)
+	s.mu.RLock()
+	auth, ok := s.auths[host]
+	s.mu.RUnlock()
+	if ok {
+		return auth, true
+	}
+	config := s.dockerConfig()
+	if config == nil {
+		return registry.AuthConfig{}, false
+	}
+	s.mu.RLock()
+	cached, ok := s.cache[host]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.auth, cached.found
+	}
+	// 没有凭据的结果也缓存，避免每次操作都调用凭据助手
+	auth, ok = config.lookup(host)
+	s.mu.Lock()
+	s.cache[host] = cachedCredential{auth: auth, found: ok, expires: time.Now().Add(credentialCacheTTL)}
+	s.mu.Unlock()
+	return auth, ok
+}
+
+// ForHosts 返回指定仓库主机的凭据，键为仓库地址，用于构建时拉取 Dockerfile 引用的镜像
+func (s *CredentialStore) ForHosts(hosts []string) map[string]registry.AuthConfig {
+	auths := make(map[string]registry.AuthConfig, len(hosts))
+	for _, host := range hosts {
+		host = RegistryHost(host)
+		if auth, ok := s.Get(host); ok {
+			auths[registryAddress(host)] = auth
+		}
+	}
+	return auths
+}
+
+// All 返回所有已知仓库的凭据，键为仓库地址。每个仓库都可能调用一次凭据助手，仓库较多时开销明显，
+// 只在无法确定构建需要哪些仓库时使用，其余情况使用 ForHosts
+func (s *CredentialStore) All() map[string]registry.AuthConfig {
+	hosts := make(map[string]bool)
+	if config := s.dockerConfig(); config != nil {
+		for address := range config.Auths {
+			hosts[RegistryHost(address)] = true
+		}
+		for address := range config.CredHelpers {
+			hosts[RegistryHost(address)] = true
+		}
+	}
+	s.mu.RLock()
+	for host := range s.auths {
+		hosts[host] = true
+	}
+	s.mu.RUnlock()
+	list := make([]string, 0, len(hosts))
+	for host := range hosts {
+		list = append(list, host)
+	}
+	return s.ForHosts(list)
+}
+
+// EncodedAuth 返回镜像所在仓库的 RegistryAuth 头，没有凭据时返回空字符串
+func (s *CredentialStore) EncodedAuth(imageRef string) (string, error) {
+	host, err := ImageRegistryHost(imageRef)
+	if err != nil {
+		return "", err
+	}
+	auth, ok := s.Get(host)
+	if !ok {
+		return "", nil
+	}
+	return registry.EncodeAuthConfig(auth)
+}
+
+// dockerConfig 读取 DOCKER_CONFIG 或 ~/.docker 下的 config.json，文件修改时间变化后重新读取
+func (s *CredentialStore) dockerConfig() *dockerConfig {
+	path := dockerConfigPath()
+	var modTime time.Time
+	if path != "" {
+		if info, err := os.Stat(path); err == nil {
+			modTime = info.ModTime()
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded && path == s.configPath && modTime.Equal(s.configModTime) {
+		return s.config
+	}
+	// 按旧配置查到的凭据可能已经失效
+	s.loaded, s.configPath, s.configModTime = true, path, modTime
+	s.config = nil
+	s.cache = make(map[string]cachedCredential)
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logs.WarnWithFields("Read docker config failed", map[string]interface{}{"path": path, "error": err})
+		}
+		return nil
+	}
+	var config dockerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		logs.WarnWithFields("Parse docker config failed", map[string]interface{}{"path": path, "error": err})
+		return nil
+	}
+	s.config = &config
+	logs.InfoWithFields("Docker config loaded", map[string]interface{}{"path": path, "auths": len(config.Auths), "credsStore": config.CredsStore, "credHelpers": len(config.CredHelpers)})
+	return s.config
+}
+
+func dockerConfigPath() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+func (c *dockerConfig) lookup(host string) (registry.AuthConfig, bool) {
+	for address, helper := range c.CredHelpers {
+		if RegistryHost(address) == host {
+			return credentialHelperGet(helper, host)
+		}
+	}
+	if c.CredsStore != "" {
+		if auth, ok := credentialHelperGet(c.CredsStore, host); ok {
+			return auth, true
+		}
+	}
+	for address, entry := range c.Auths {
+		if RegistryHost(address) != host {
+			continue
+		}
+		auth := registry.AuthConfig{
+			Username:      entry.Username,
+			Password:      entry.Password,
+			IdentityToken: entry.IdentityToken,
+			ServerAddress: address,
+		}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				logs.WarnWithFields("Decode docker config auth failed", map[string]interface{}{"registry": host, "error": err})
+				continue
+			}
+			auth.Username, auth.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		if auth.Username == "" && auth.IdentityToken == "" {
+			continue
+		}
+		return auth, true
+	}
+	return registry.AuthConfig{}, false
+}
+
+// credentialHelperGet 调用 docker-credential-<helper> get 读取凭据
+func credentialHelperGet(helper, host string) (registry.AuthConfig, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialHelperTimeout)
+	defer cancel()
+	address := registryAddress(host)
+	cmd := exec.CommandContext(ctx, "docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(address)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// 助手对没有凭据的仓库也会返回错误，只记录调试日志
+		logs.DebugWithFields("Credential helper get failed", map[string]interface{}{"helper": helper, "registry": host, "error": fmt.Sprintf("%v %s", err, strings.TrimSpace(stderr.String()))})
+		return registry.AuthConfig{}, false
+	}
+	var creds struct {
+		ServerURL string
+		Username  string
+		Secret    string
+	}
+	if err := json.Unmarshal(out, &creds); err != nil || creds.Secret == "" {
+		return registry.AuthConfig{}, false
+	}
+	auth := registry.AuthConfig{ServerAddress: address}
+	// 用户名为 <token> 时 Secret 是身份令牌
+	if creds.Username == "<token>" {
+		auth.IdentityToken = creds.Secret
+	} else {
+		auth.Username, auth.Password = creds.Username, creds.Secret
+	}
+	logs.InfoWithFields("Registry credential loaded from helper", map[string]interface{}{"helper": helper, "registry": host})
+	return auth, true
+}
+
+// registryAddress config.json 和凭据助手中 Docker Hub 使用旧的索引地址，其他仓库使用主机名
+func registryAddress(host string) string {
+	if host == DockerHubHost {
+		return DockerHubAddress
+	}
+	return host
+}
+
+// ImageRegistryHost 解析镜像引用所在的仓库主机，未带主机的镜像属于 Docker Hub
+func ImageRegistryHost(imageRef string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(imageRef)
+	if err != nil {
+		return "", err
+	}
+	return RegistryHost(reference.Domain(named)), nil
+}
+
+// RegistryHost 把 https://index.docker.io/v1/、registry.example.com:5000/v2 等地址统一为主机名
+func RegistryHost(address string) string {
+	host := strings.TrimSpace(address)
+	if strings.Contains(host, "://") {
+		if u, err := url.Parse(host); err == nil {
+			host = u.Host
+		}
+	}
+	host, _, _ = strings.Cut(host, "/")
+	host = strings.ToLower(host)
+	switch host {
+	case "", "docker.io", "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return DockerHubHost
+	}
+	return host
+}
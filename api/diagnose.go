@@ -0,0 +1,443 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"encoding/json"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultDiagnoseLogLines = 50
+	DefaultDiagnoseEvents   = time.Hour
+	// reachabilityTimeout 端口探测的超时时间
+	reachabilityTimeout = 2 * time.Second
+	// memoryPressurePercent 内存使用超过该比例视为接近上限
+	memoryPressurePercent = 90
+)
+
+var (
+	portAllocatedPattern = regexp.MustCompile(`(?i)port is already allocated|address already in use|bind: address`)
+	// missingEnvPatterns 常见的缺少环境变量的报错
+	missingEnvPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)(environment variable|env var)\S*\s+\W?([A-Z][A-Z0-9_]+)\W?\s+(is\s+)?(not set|missing|required|undefined|must be (set|specified|defined))`),
+		regexp.MustCompile(`(?i)(missing|required|undefined|unset)\s+(required\s+)?(environment variable|env var)s?\W*\s*\W?([A-Z][A-Z0-9_]+)?`),
+		regexp.MustCompile(`\b([A-Z][A-Z0-9]*_[A-Z0-9_]+)\b\W*\s+(is\s+)?(not set|must be (set|specified|defined)|is required|is missing)`),
+		regexp.MustCompile(`(?i)you need to specify one of the following`),
+		regexp.MustCompile(`(?:must|need to) specify\s+([A-Z][A-Z0-9_]+)`),
+		regexp.MustCompile(`KeyError: '([A-Z][A-Z0-9_]+)'`),
+	}
+)
+
+type DiagnoseOptions struct {
+	// LogLines 返回的最近日志行数
+	LogLines int
+	// EventsSince 收集多长时间内的事件
+	EventsSince time.Duration
+}
+
+// DiagnoseContainer 汇总容器的状态、健康检查、日志、事件、资源和网络信息，并推断可能的故障原因
+// 单项信息获取失败只记录在 Errors 中，不影响其余部分
+func DiagnoseContainer(ctx context.Context, cli *client.Client, containerID string, opts DiagnoseOptions) (*resp.ContainerDiagnosis, error) {
+	if opts.LogLines <= 0 {
+		opts.LogLines = DefaultDiagnoseLogLines
+	}
+	if opts.EventsSince <= 0 {
+		opts.EventsSince = DefaultDiagnoseEvents
+	}
+	inspect, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		logs.ErrorWithFields("ContainerInspect failed", map[string]interface{}{"id": containerID, "error": err})
+		return nil, err
+	}
+	report := &resp.ContainerDiagnosis{
+		ID:     inspect.ID,
+		Name:   strings.TrimPrefix(inspect.Name, "/"),
+		Logs:   []string{},
+		Events: []resp.DiagnosisEvent{},
+		Causes: []resp.DiagnosisCause{},
+		Errors: map[string]string{},
+	}
+	if inspect.Config != nil {
+		report.Image = inspect.Config.Image
+	}
+	if inspect.State != nil {
+		report.State = resp.DiagnosisState{
+			Status:       inspect.State.Status,
+			Running:      inspect.State.Running,
+			Restarting:   inspect.State.Restarting,
+			OOMKilled:    inspect.State.OOMKilled,
+			ExitCode:     inspect.State.ExitCode,
+			Error:        inspect.State.Error,
+			StartedAt:    inspect.State.StartedAt,
+			FinishedAt:   inspect.State.FinishedAt,
+			RestartCount: inspect.RestartCount,
+		}
+		if h := inspect.State.Health; h != nil {
+			report.Health = &resp.DiagnosisHealth{Status: h.Status, FailingStreak: h.FailingStreak}
+			if inspect.Config != nil && inspect.Config.Healthcheck != nil {
+				report.Health.Test = inspect.Config.Healthcheck.Test
+			}
+			for _, probe := range h.Log {
+				report.Health.Log = append(report.Health.Log, resp.DiagnosisHealthProbe{
+					Start:    probe.Start.Format(time.RFC3339),
+					ExitCode: probe.ExitCode,
+					Output:   strings.TrimSpace(probe.Output),
+				})
+			}
+		}
+	}
+
+	if lines, err := tailLogs(ctx, cli, inspect, opts.LogLines); err != nil {
+		report.Errors["logs"] = err.Error()
+	} else {
+		report.Logs = lines
+	}
+	if evts, err := recentEvents(ctx, cli, inspect.ID, opts.EventsSince); err != nil {
+		report.Errors["events"] = err.Error()
+	} else {
+		report.Events = evts
+	}
+	if inspect.State != nil && inspect.State.Running {
+		if usage, err := containerUsage(ctx, cli, inspect); err != nil {
+			report.Errors["stats"] = err.Error()
+		} else {
+			report.Usage = usage
+		}
+	}
+	probeHost, probeLocal := daemonProbeHost(cli)
+	report.Networks, report.Ports = probeNetwork(inspect, probeHost, probeLocal)
+
+	report.Causes = detectCauses(inspect, report)
+	if len(report.Errors) == 0 {
+		report.Errors = nil
+	}
+	logs.InfoWithFields("DiagnoseContainer success", map[string]interface{}{"id": inspect.ID, "causes": len(report.Causes)})
+	return report, nil
+}
+
+// tailLogs 读取最后 n 行日志，非 TTY 容器的输出需要用 stdcopy 解复用
+func tailLogs(ctx context.Context, cli *client.Client, inspect container.InspectResponse, n int) ([]string, error) {
+	reader, err := cli.ContainerLogs(ctx, inspect.ID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(n),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	var buf bytes.Buffer
+	if inspect.Config != nil && inspect.Config.Tty {
+		_, err = io.Copy(&buf, reader)
+	} else {
+		_, err = stdcopy.StdCopy(&buf, &buf, reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0, n)
+	scanner := bufio.NewScanner(&buf)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	return lines, scanner.Err()
+}
+
+func recentEvents(ctx context.Context, cli *client.Client, containerID string, since time.Duration) ([]resp.DiagnosisEvent, error) {
+	now := time.Now()
+	args := filters.NewArgs(filters.Arg("type", string(events.ContainerEventType)), filters.Arg("container", containerID))
+	// 指定 until 后事件流会在返回历史事件后结束
+	messages, errs := cli.Events(ctx, events.ListOptions{
+		Since:   strconv.FormatInt(now.Add(-since).Unix(), 10),
+		Until:   strconv.FormatInt(now.Unix(), 10),
+		Filters: args,
+	})
+	result := make([]resp.DiagnosisEvent, 0)
+	for {
+		select {
+		case msg := <-messages:
+			// exec 事件过于频繁，只保留和生命周期相关的属性
+			attributes := make(map[string]string)
+			for _, key := range []string{"exitCode", "signal", "execDuration"} {
+				if val, ok := msg.Actor.Attributes[key]; ok {
+					attributes[key] = val
+				}
+			}
+			if len(attributes) == 0 {
+				attributes = nil
+			}
+			result = append(result, resp.DiagnosisEvent{
+				Time:       time.Unix(0, msg.TimeNano).Format(time.RFC3339),
+				Action:     string(msg.Action),
+				Attributes: attributes,
+			})
+		case err := <-errs:
+			if err == io.EOF {
+				return result, nil
+			}
+			return result, err
+		}
+	}
+}
+
+func containerUsage(ctx context.Context, cli *client.Client, inspect container.InspectResponse) (*resp.DiagnosisUsage, error) {
+	// 非流式的 stats 会等待一个采样周期，带有 precpu 数据用于计算 CPU 使用率
+	stats, err := cli.ContainerStats(ctx, inspect.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	defer stats.Body.Close()
+	var s container.StatsResponse
+	if err := json.NewDecoder(stats.Body).Decode(&s); err != nil {
+		return nil, err
+	}
+	usage := &resp.DiagnosisUsage{
+		MemoryUsage:   memoryWorkingSet(s.MemoryStats),
+		MemoryLimit:   s.MemoryStats.Limit,
+		MemoryFailcnt: s.MemoryStats.Failcnt,
+		Throttled:     s.CPUStats.ThrottlingData.ThrottledPeriods,
+		Pids:          s.PidsStats.Current,
+		PidsLimit:     s.PidsStats.Limit,
+	}
+	if usage.MemoryLimit > 0 {
+		usage.MemoryPercent = round2(float64(usage.MemoryUsage) / float64(usage.MemoryLimit) * 100)
+	}
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		onlineCPUs := float64(s.CPUStats.OnlineCPUs)
+		if onlineCPUs == 0 {
+			onlineCPUs = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+		}
+		usage.CPUPercent = round2(cpuDelta / systemDelta * onlineCPUs * 100)
+	}
+	if inspect.HostConfig != nil {
+		if inspect.HostConfig.NanoCPUs > 0 {
+			usage.CPULimit = float64(inspect.HostConfig.NanoCPUs) / 1e9
+		} else if inspect.HostConfig.CPUQuota > 0 && inspect.HostConfig.CPUPeriod > 0 {
+			usage.CPULimit = float64(inspect.HostConfig.CPUQuota) / float64(inspect.HostConfig.CPUPeriod)
+		}
+	}
+	return usage, nil
+}
+
+// memoryWorkingSet 与 docker stats 一致，去掉页缓存后的内存使用量
+func memoryWorkingSet(m container.MemoryStats) uint64 {
+	for _, key := range []string{"inactive_file", "total_inactive_file"} {
+		if cache, ok := m.Stats[key]; ok && cache < m.Usage {
+			return m.Usage - cache
+		}
+	}
+	return m.Usage
+}
+
+// daemonProbeHost 返回探测已发布端口时连接的主机名。TCP 连接的远程 daemon 返回其主机名，
+// unix socket 或 npipe 连接的本地 daemon 返回 127.0.0.1；local 表示 daemon 与 MCP 服务在同一主机
+func daemonProbeHost(cli *client.Client) (host string, local bool) {
+	u, err := client.ParseHostURL(cli.DaemonHost())
+	if err != nil || (u.Scheme != "tcp" && u.Scheme != "http" && u.Scheme != "https") {
+		return "127.0.0.1", true
+	}
+	host = u.Host
+	if h, _, err := net.SplitHostPort(u.Host); err == nil {
+		host = h
+	}
+	if host == "" {
+		return "127.0.0.1", true
+	}
+	return host, isLoopbackHost(host)
+}
+
+// probeNetwork 从 MCP 服务所在主机探测已发布的 TCP 端口。未发布的端口只有 daemon 在本机时才连接容器IP，
+// 远程 daemon 的容器IP和绑定到其回环地址的端口从这里无法访问，标记为未探测而不是不可达
+func probeNetwork(inspect container.InspectResponse, daemonHost string, local bool) ([]resp.DiagnosisNetwork, []resp.DiagnosisPort) {
+	if inspect.NetworkSettings == nil {
+		return nil, nil
+	}
+	var networks []resp.DiagnosisNetwork
+	containerIP := ""
+	for _, name := range sortedKeys(inspect.NetworkSettings.Networks) {
+		endpoint := inspect.NetworkSettings.Networks[name]
+		if endpoint == nil {
+			continue
+		}
+		networks = append(networks, resp.DiagnosisNetwork{Name: name, IPAddress: endpoint.IPAddress, Gateway: endpoint.Gateway})
+		if containerIP == "" {
+			containerIP = endpoint.IPAddress
+		}
+	}
+	running := inspect.State != nil && inspect.State.Running
+	var ports []resp.DiagnosisPort
+	for _, port := range sortedKeys(inspect.NetworkSettings.Ports) {
+		bindings := inspect.NetworkSettings.Ports[port]
+		if len(bindings) == 0 {
+			p := resp.DiagnosisPort{ContainerPort: string(port)}
+			if running && port.Proto() == "tcp" && containerIP != "" {
+				if local {
+					p.Probed = true
+					p.Reachable, p.Error = dialTCP(containerIP, port.Port())
+				} else {
+					p.NotProbed = "container IP is not reachable from a remote Docker daemon"
+				}
+			}
+			ports = append(ports, p)
+			continue
+		}
+		for _, b := range bindings {
+			p := resp.DiagnosisPort{ContainerPort: string(port), HostIP: b.HostIP, HostPort: b.HostPort}
+			if running && port.Proto() == "tcp" {
+				host := b.HostIP
+				if host == "" || host == "0.0.0.0" || host == "::" {
+					host = daemonHost
+				}
+				if !local && isLoopbackHost(host) {
+					p.NotProbed = "port is bound to the loopback address of a remote Docker daemon"
+				} else {
+					p.Probed = true
+					p.Reachable, p.Error = dialTCP(host, b.HostPort)
+				}
+			}
+			ports = append(ports, p)
+		}
+	}
+	return networks, ports
+}
+
+func dialTCP(host, port string) (bool, string) {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), reachabilityTimeout)
+	if err != nil {
+		return false, err.Error()
+	}
+	conn.Close()
+	return true, ""
+}
+
+// detectCauses 根据已收集的信息推断可能的故障原因
+func detectCauses(inspect container.InspectResponse, report *resp.ContainerDiagnosis) []resp.DiagnosisCause {
+	causes := make([]resp.DiagnosisCause, 0)
+	state := report.State
+	var memoryLimit int64
+	if inspect.HostConfig != nil {
+		memoryLimit = inspect.HostConfig.Memory
+	}
+
+	if state.OOMKilled {
+		evidence := []string{"State.OOMKilled=true"}
+		if memoryLimit > 0 {
+			evidence = append(evidence, fmt.Sprintf("memory limit %s", formatBytes(memoryLimit)))
+		}
+		causes = append(causes, resp.DiagnosisCause{Code: "oom", Summary: "The container was killed because it ran out of memory; raise the memory limit or reduce usage", Evidence: evidence})
+	} else if state.ExitCode == 137 && !state.Running {
+		causes = append(causes, resp.DiagnosisCause{Code: "killed", Summary: "The process was killed with SIGKILL (exit code 137), by docker kill/stop timeout or the kernel OOM killer", Evidence: []string{"exit code 137"}})
+	}
+	if u := report.Usage; u != nil && u.MemoryLimit > 0 && u.MemoryPercent >= memoryPressurePercent {
+		causes = append(causes, resp.DiagnosisCause{Code: "memory_pressure", Summary: "Memory usage is close to the limit and the container may be OOM-killed soon", Evidence: []string{fmt.Sprintf("memory %.2f%% of limit", u.MemoryPercent)}})
+	}
+	if u := report.Usage; u != nil && u.PidsLimit > 0 && u.Pids >= u.PidsLimit {
+		causes = append(causes, resp.DiagnosisCause{Code: "pids_limit", Summary: "The container reached its pids limit and cannot create new processes", Evidence: []string{fmt.Sprintf("pids %d of %d", u.Pids, u.PidsLimit)}})
+	}
+
+	if portAllocatedPattern.MatchString(state.Error) {
+		causes = append(causes, resp.DiagnosisCause{Code: "port_allocated", Summary: "A published host port is already in use by another container or process; choose another host port or stop the other user", Evidence: []string{state.Error}})
+	}
+	if strings.Contains(state.Error, "executable file not found") || strings.Contains(state.Error, "no such file or directory") || state.ExitCode == 127 {
+		evidence := []string{fmt.Sprintf("exit code %d", state.ExitCode)}
+		if state.Error != "" {
+			evidence = append(evidence, state.Error)
+		}
+		causes = append(causes, resp.DiagnosisCause{Code: "command_not_found", Summary: "The entrypoint or command does not exist in the image", Evidence: evidence})
+	} else if state.ExitCode == 126 || strings.Contains(state.Error, "permission denied") {
+		causes = append(causes, resp.DiagnosisCause{Code: "permission_denied", Summary: "The command could not be executed, check file permissions and the user", Evidence: []string{fmt.Sprintf("exit code %d", state.ExitCode), state.Error}})
+	}
+
+	if evidence := matchLogLines(report.Logs, missingEnvPatterns); len(evidence) > 0 {
+		causes = append(causes, resp.DiagnosisCause{Code: "missing_env", Summary: "The application reports a missing or empty environment variable", Evidence: evidence})
+	}
+
+	if h := report.Health; h != nil && (h.Status == "unhealthy" || h.FailingStreak > 0) {
+		evidence := []string{fmt.Sprintf("health %s, failing streak %d", h.Status, h.FailingStreak)}
+		if len(h.Log) > 0 {
+			last := h.Log[len(h.Log)-1]
+			evidence = append(evidence, fmt.Sprintf("last probe exit code %d: %s", last.ExitCode, truncate(last.Output, 500)))
+		}
+		causes = append(causes, resp.DiagnosisCause{Code: "healthcheck_failing", Summary: "The healthcheck command is failing", Evidence: evidence})
+	}
+
+	if state.Restarting || state.RestartCount >= 3 {
+		evidence := []string{fmt.Sprintf("restart count %d", state.RestartCount)}
+		starts := 0
+		for _, e := range report.Events {
+			if e.Action == string(events.ActionDie) {
+				starts++
+			}
+		}
+		if starts > 0 {
+			evidence = append(evidence, fmt.Sprintf("%d die events in the collected window", starts))
+		}
+		causes = append(causes, resp.DiagnosisCause{Code: "crash_loop", Summary: "The container keeps exiting and being restarted by its restart policy", Evidence: evidence})
+	}
+
+	if state.Running {
+		var closed []string
+		for _, p := range report.Ports {
+			if p.Probed && p.Error != "" {
+				closed = append(closed, fmt.Sprintf("%s (%s:%s): %s", p.ContainerPort, p.HostIP, p.HostPort, p.Error))
+			}
+		}
+		if len(closed) > 0 {
+			causes = append(causes, resp.DiagnosisCause{Code: "port_unreachable", Summary: "Some ports do not accept TCP connections; the application may not be listening yet, may bind to 127.0.0.1 inside the container, or the MCP server cannot reach the Docker host", Evidence: closed})
+		}
+	} else if state.ExitCode != 0 && len(causes) == 0 {
+		evidence := []string{fmt.Sprintf("exit code %d", state.ExitCode)}
+		if n := len(report.Logs); n > 0 {
+			evidence = append(evidence, report.Logs[max(0, n-5):]...)
+		}
+		causes = append(causes, resp.DiagnosisCause{Code: "exited_with_error", Summary: "The main process exited with a non-zero code, see the last log lines", Evidence: evidence})
+	}
+	return causes
+}
+
+func matchLogLines(lines []string, patterns []*regexp.Regexp) []string {
+	var matched []string
+	for _, line := range lines {
+		for _, pattern := range patterns {
+			if pattern.MatchString(line) {
+				matched = append(matched, truncate(line, 500))
+				break
+			}
+		}
+		if len(matched) >= 5 {
+			break
+		}
+	}
+	return matched
+}
+
+// truncate 按字符截断，不会切开多字节的 UTF-8 字符
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
+
+func round2(f float64) float64 {
+	return float64(int64(f*100+0.5)) / 100
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/resp"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"io"
+	"strconv"
+	"time"
+)
+
+// EventsOptions 对应 docker events 的时间范围和过滤条件
+type EventsOptions struct {
+	// Since、Until 支持 RFC3339 时间、Unix 时间戳或相对当前的时长，例如 10m
+	Since     string   `json:"since,omitempty"`
+	Until     string   `json:"until,omitempty"`
+	Type      []string `json:"type,omitempty"`
+	Action    []string `json:"action,omitempty"`
+	Container []string `json:"container,omitempty"`
+	Image     []string `json:"image,omitempty"`
+	Label     []string `json:"label,omitempty"`
+}
+
+func (o EventsOptions) build(now time.Time) (events.ListOptions, error) {
+	args := filters.NewArgs()
+	for key, values := range map[string][]string{
+		"type":      o.Type,
+		"event":     o.Action,
+		"container": o.Container,
+		"image":     o.Image,
+		"label":     o.Label,
+	} {
+		for _, v := range values {
+			args.Add(key, v)
+		}
+	}
+	options := events.ListOptions{Filters: args}
+	var err error
+	if options.Since, err = eventTimestamp(o.Since, now); err != nil {
+		return options, fmt.Errorf("invalid since %q: %v", o.Since, err)
+	}
+	if options.Until, err = eventTimestamp(o.Until, now); err != nil {
+		return options, fmt.Errorf("invalid until %q: %v", o.Until, err)
+	}
+	return options, nil
+}
+
+// eventTimestamp 把时间参数转换为 API 需要的 Unix 时间戳
+func eventTimestamp(value string, now time.Time) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return strconv.FormatInt(now.Add(-d).Unix(), 10), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return strconv.FormatInt(t.Unix(), 10), nil
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value, nil
+	}
+	return "", errors.New("expected RFC3339 time, unix timestamp or duration such as 10m")
+}
+
+// QueryEvents 查询历史事件，未指定 until 时截止到当前时间，limit 大于0时只保留最近的 limit 条
+func QueryEvents(ctx context.Context, cli *client.Client, opts EventsOptions, limit int) ([]resp.Event, bool, error) {
+	now := time.Now()
+	if opts.Since == "" {
+		return nil, false, errors.New("since is required when querying history")
+	}
+	if opts.Until == "" {
+		opts.Until = strconv.FormatInt(now.Unix(), 10)
+	}
+	options, err := opts.build(now)
+	if err != nil {
+		return nil, false, err
+	}
+	messages, errs := cli.Events(ctx, options)
+	result := make([]resp.Event, 0)
+	truncated := false
+	for {
+		select {
+		case msg := <-messages:
+			result = append(result, ToEvent(msg))
+			if limit > 0 && len(result) > limit {
+				result = result[1:]
+				truncated = true
+			}
+		case err := <-errs:
+			if err == io.EOF {
+				return result, truncated, nil
+			}
+			return result, truncated, err
+		}
+	}
+}
+
+// WatchEvents 订阅新事件直到 ctx 结束，每个事件交给 handler 处理，handler 返回 false 时提前结束
+func WatchEvents(ctx context.Context, cli *client.Client, opts EventsOptions, handler func(resp.Event) bool) error {
+	options, err := opts.build(time.Now())
+	if err != nil {
+		return err
+	}
+	messages, errs := cli.Events(ctx, options)
+	for {
+		select {
+		case msg := <-messages:
+			if !handler(ToEvent(msg)) {
+				return nil
+			}
+		case err := <-errs:
+			// 订阅时间到期属于正常结束
+			if err == io.EOF || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func ToEvent(msg events.Message) resp.Event {
+	event := resp.Event{
+		Time:   time.Unix(0, msg.TimeNano).Format(time.RFC3339Nano),
+		Type:   string(msg.Type),
+		Action: string(msg.Action),
+		ID:     msg.Actor.ID,
+		Name:   msg.Actor.Attributes["name"],
+	}
+	if len(msg.Actor.Attributes) > 0 {
+		event.Attributes = msg.Actor.Attributes
+	}
+	return event
+}
@@ -0,0 +1,426 @@
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"encoding/base64"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultMaxFileBytes 读写容器文件的默认大小上限
+const DefaultMaxFileBytes = 1 << 20
+
+// 跟随符号链接的最大次数，防止循环链接
+const maxSymlinkHops = 8
+
+// 二进制检测时检查的字节数
+const binarySniffLen = 8000
+
+// DefaultDirListLimit 列目录时默认返回的最大条目数
+const DefaultDirListLimit = 1000
+
+// dirScanFactor CopyFromContainer 总是返回整棵子树，无论过滤条件如何，最多读取 Limit 的这个倍数个 tar 条目
+const dirScanFactor = 10
+
+type DirListOptions struct {
+	// Depth 递归深度，1 表示只列出直接子条目，0 表示不限制
+	Depth int
+	// Pattern glob 过滤，例如 *.conf
+	Pattern string
+	// Limit 返回的最大条目数，同时限制读取的 tar 条目数为 Limit*dirScanFactor
+	Limit int
+}
+
+type FileWriteOptions struct {
+	// Mode 八进制权限，例如 0644；为空时沿用已有文件的权限，新文件默认 0644
+	Mode string
+	// Owner 格式 uid[:gid]；为空时沿用已有文件的属主，新文件默认 0:0
+	Owner string
+	// MaxBytes 写入内容的大小上限
+	MaxBytes int64
+}
+
+// ReadContainerPath 读取容器内的文件或目录，文本文件直接返回内容，二进制文件返回base64，目录返回条目列表
+func ReadContainerPath(ctx context.Context, cli *client.Client, containerID, filePath string, maxBytes int64) (*resp.ContainerFile, error) {
+	if !path.IsAbs(filePath) {
+		return nil, fmt.Errorf("path must be absolute: %s", filePath)
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxFileBytes
+	}
+	filePath = path.Clean(filePath)
+	resolved, stat, linkTarget, err := resolveContainerPath(ctx, cli, containerID, filePath)
+	if err != nil {
+		return nil, err
+	}
+	file := &resp.ContainerFile{
+		Container:  containerID,
+		Path:       filePath,
+		LinkTarget: linkTarget,
+	}
+	file.Type = fileType(stat.Mode)
+	file.Size = stat.Size
+	file.Mode = formatMode(stat.Mode)
+	file.Mtime = stat.Mtime
+
+	switch {
+	case stat.Mode.IsDir():
+		entries, truncated, err := ListContainerDir(ctx, cli, containerID, resolved, DirListOptions{Depth: 1})
+		if err != nil {
+			return nil, err
+		}
+		file.Entries = entries
+		file.Truncated = truncated
+	case stat.Mode.IsRegular():
+		if stat.Size > maxBytes {
+			return nil, fmt.Errorf("file %s is %d bytes, exceeds the limit of %d bytes", filePath, stat.Size, maxBytes)
+		}
+		data, _, err := readContainerFile(ctx, cli, containerID, resolved, maxBytes)
+		if err != nil {
+			return nil, err
+		}
+		if isBinary(data) {
+			file.Encoding = "base64"
+			file.Content = base64.StdEncoding.EncodeToString(data)
+		} else {
+			file.Encoding = "text"
+			file.Content = string(data)
+		}
+	default:
+		return nil, fmt.Errorf("%s is a %s and cannot be read", filePath, file.Type)
+	}
+	logs.InfoWithFields("ReadContainerPath success", map[string]interface{}{"id": containerID, "path": filePath, "type": file.Type})
+	return file, nil
+}
+
+// ListContainerPath 列出容器内的目录，不依赖容器内的shell，适用于distroless和scratch镜像
+func ListContainerPath(ctx context.Context, cli *client.Client, containerID, dir string, opts DirListOptions) (*resp.ContainerFile, error) {
+	if !path.IsAbs(dir) {
+		return nil, fmt.Errorf("path must be absolute: %s", dir)
+	}
+	if opts.Pattern != "" {
+		if _, err := path.Match(opts.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", opts.Pattern, err)
+		}
+	}
+	dir = path.Clean(dir)
+	resolved, stat, linkTarget, err := resolveContainerPath(ctx, cli, containerID, dir)
+	if err != nil {
+		return nil, err
+	}
+	listing := &resp.ContainerFile{
+		Container:  containerID,
+		Path:       dir,
+		Type:       fileType(stat.Mode),
+		Size:       stat.Size,
+		Mode:       formatMode(stat.Mode),
+		Mtime:      stat.Mtime,
+		LinkTarget: linkTarget,
+	}
+	if !stat.Mode.IsDir() {
+		return nil, fmt.Errorf("%s is a %s, not a directory", dir, listing.Type)
+	}
+	entries, truncated, err := ListContainerDir(ctx, cli, containerID, resolved, opts)
+	if err != nil {
+		return nil, err
+	}
+	listing.Entries = entries
+	listing.Truncated = truncated
+	logs.InfoWithFields("ListContainerPath success", map[string]interface{}{"id": containerID, "path": dir, "entries": len(entries)})
+	return listing, nil
+}
+
+// ListContainerDir 通过CopyFromContainer返回的tar流列出目录下的条目，条目名称为相对于dir的路径
+func ListContainerDir(ctx context.Context, cli *client.Client, containerID, dir string, opts DirListOptions) ([]resp.ContainerFileEntry, bool, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultDirListLimit
+	}
+	stream, _, err := cli.CopyFromContainer(ctx, containerID, dir)
+	if err != nil {
+		logs.ErrorWithFields("CopyFromContainer failed", map[string]interface{}{"id": containerID, "path": dir, "error": err})
+		return nil, false, err
+	}
+	// 达到数量上限后直接关闭流，不再读取剩余内容
+	defer stream.Close()
+
+	entries := make([]resp.ContainerFileEntry, 0)
+	tr := tar.NewReader(stream)
+	root := ""
+	first := true
+	scanned := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("read archive of %s failed: %v", dir, err)
+		}
+		// 被深度或 pattern 过滤掉的条目也计入，避免为了列出少量条目读完整个文件系统
+		scanned++
+		if scanned > opts.Limit*dirScanFactor {
+			return entries, true, nil
+		}
+		name := strings.Trim(strings.TrimPrefix(hdr.Name, "./"), "/")
+		// 第一个条目是目录本身，后续条目都以它为前缀
+		if first {
+			first = false
+			root = name
+			continue
+		}
+		rel := name
+		if root != "" && root != "." {
+			rel = strings.TrimPrefix(name, root+"/")
+		}
+		if rel == "" {
+			continue
+		}
+		if opts.Depth > 0 && strings.Count(rel, "/") >= opts.Depth {
+			continue
+		}
+		if !matchEntry(opts.Pattern, rel) {
+			continue
+		}
+		if len(entries) >= opts.Limit {
+			return entries, true, nil
+		}
+		entries = append(entries, entryFromHeader(rel, hdr))
+	}
+	return entries, false, nil
+}
+
+// WriteContainerFile 通过CopyToContainer把内容写入容器内的文件
+func WriteContainerFile(ctx context.Context, cli *client.Client, containerID, filePath string, content []byte, opts FileWriteOptions) (*resp.ContainerFileWrite, error) {
+	if !path.IsAbs(filePath) {
+		return nil, fmt.Errorf("path must be absolute: %s", filePath)
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxFileBytes
+	}
+	if int64(len(content)) > opts.MaxBytes {
+		return nil, fmt.Errorf("content is %d bytes, exceeds the limit of %d bytes", len(content), opts.MaxBytes)
+	}
+	filePath = path.Clean(filePath)
+	if filePath == "/" {
+		return nil, fmt.Errorf("path must point to a file")
+	}
+
+	// 符号链接写到真实目标，不替换链接本身；只有普通文件沿用原有的权限和属主
+	mode := int64(0644)
+	uid, gid := 0, 0
+	created := true
+	target := filePath
+	resolved, stat, linkTarget, err := resolveContainerPath(ctx, cli, containerID, filePath)
+	if err == nil {
+		if stat.Mode.IsDir() {
+			return nil, fmt.Errorf("%s is a directory", filePath)
+		}
+		target = resolved
+		_, existing, err := readContainerFile(ctx, cli, containerID, target, 0)
+		if err != nil {
+			return nil, err
+		}
+		if existing.Typeflag == tar.TypeReg {
+			mode = existing.Mode & 07777
+			uid, gid = existing.Uid, existing.Gid
+		}
+		created = false
+	} else if !client.IsErrNotFound(err) {
+		return nil, err
+	}
+	if opts.Mode != "" {
+		parsed, err := strconv.ParseInt(opts.Mode, 8, 32)
+		if err != nil || parsed < 0 || parsed > 07777 {
+			return nil, fmt.Errorf("invalid mode %q, expected octal such as 0644", opts.Mode)
+		}
+		mode = parsed
+	}
+	if opts.Owner != "" {
+		if uid, gid, err = parseOwner(opts.Owner); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     path.Base(target),
+		Typeflag: tar.TypeReg,
+		Mode:     mode,
+		Size:     int64(len(content)),
+		Uid:      uid,
+		Gid:      gid,
+		ModTime:  time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(content); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := cli.CopyToContainer(ctx, containerID, path.Dir(target), &buf, container.CopyToContainerOptions{
+		CopyUIDGID: true,
+	}); err != nil {
+		logs.ErrorWithFields("CopyToContainer failed", map[string]interface{}{"id": containerID, "path": target, "error": err})
+		return nil, err
+	}
+	logs.InfoWithFields("WriteContainerFile success", map[string]interface{}{"id": containerID, "path": target, "size": len(content)})
+	return &resp.ContainerFileWrite{
+		Container:  containerID,
+		Path:       filePath,
+		LinkTarget: linkTarget,
+		Size:       int64(len(content)),
+		Mode:       fmt.Sprintf("%04o", mode),
+		UID:        uid,
+		GID:        gid,
+		Created:    created,
+	}, nil
+}
+
+// resolveContainerPath 跟随符号链接直到真实目标，返回目标路径、目标信息和第一层链接指向
+func resolveContainerPath(ctx context.Context, cli *client.Client, containerID, filePath string) (string, container.PathStat, string, error) {
+	stat, err := cli.ContainerStatPath(ctx, containerID, filePath)
+	if err != nil {
+		logs.ErrorWithFields("ContainerStatPath failed", map[string]interface{}{"id": containerID, "path": filePath, "error": err})
+		return "", stat, "", err
+	}
+	linkTarget := stat.LinkTarget
+	resolved := filePath
+	for hops := 0; stat.Mode&os.ModeSymlink != 0; hops++ {
+		if hops >= maxSymlinkHops {
+			return "", stat, "", fmt.Errorf("too many levels of symbolic links: %s", filePath)
+		}
+		target := stat.LinkTarget
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(resolved), target)
+		}
+		resolved = target
+		if stat, err = cli.ContainerStatPath(ctx, containerID, resolved); err != nil {
+			logs.ErrorWithFields("ContainerStatPath failed", map[string]interface{}{"id": containerID, "path": resolved, "error": err})
+			return "", stat, "", err
+		}
+	}
+	return resolved, stat, linkTarget, nil
+}
+
+// readContainerFile 读取tar流中的第一个条目，maxBytes为0时只读取头信息
+func readContainerFile(ctx context.Context, cli *client.Client, containerID, filePath string, maxBytes int64) ([]byte, *tar.Header, error) {
+	stream, _, err := cli.CopyFromContainer(ctx, containerID, filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer stream.Close()
+	tr := tar.NewReader(stream)
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read archive of %s failed: %v", filePath, err)
+	}
+	if maxBytes == 0 {
+		return nil, hdr, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(tr, maxBytes))
+	if err != nil {
+		return nil, nil, fmt.Errorf("read %s failed: %v", filePath, err)
+	}
+	return data, hdr, nil
+}
+
+func entryFromHeader(name string, hdr *tar.Header) resp.ContainerFileEntry {
+	mode := hdr.FileInfo().Mode()
+	return resp.ContainerFileEntry{
+		Name:       name,
+		Type:       fileType(mode),
+		Size:       hdr.Size,
+		Mode:       formatMode(mode),
+		Mtime:      hdr.ModTime,
+		LinkTarget: hdr.Linkname,
+	}
+}
+
+// matchEntry 模式中不含 / 时只匹配文件名，否则匹配相对路径
+func matchEntry(pattern, rel string) bool {
+	if pattern == "" {
+		return true
+	}
+	target := path.Base(rel)
+	if strings.Contains(pattern, "/") {
+		target = rel
+	}
+	matched, _ := path.Match(pattern, target)
+	return matched
+}
+
+func parseOwner(owner string) (int, int, error) {
+	parts := strings.SplitN(owner, ":", 2)
+	uid, err := strconv.Atoi(parts[0])
+	if err != nil || uid < 0 {
+		return 0, 0, fmt.Errorf("invalid owner %q, expected numeric uid[:gid]", owner)
+	}
+	gid := uid
+	if len(parts) == 2 {
+		if gid, err = strconv.Atoi(parts[1]); err != nil || gid < 0 {
+			return 0, 0, fmt.Errorf("invalid owner %q, expected numeric uid[:gid]", owner)
+		}
+	}
+	return uid, gid, nil
+}
+
+// isBinary 包含NUL字节或不是合法UTF-8的内容视为二进制
+func isBinary(data []byte) bool {
+	sniff := data
+	if len(sniff) > binarySniffLen {
+		sniff = sniff[:binarySniffLen]
+	}
+	if bytes.IndexByte(sniff, 0) >= 0 {
+		return true
+	}
+	return !utf8.Valid(data)
+}
+
+func fileType(mode os.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "directory"
+	case mode&os.ModeSymlink != 0:
+		return "symlink"
+	case mode.IsRegular():
+		return "file"
+	case mode&os.ModeNamedPipe != 0:
+		return "pipe"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeDevice != 0:
+		return "device"
+	default:
+		return "other"
+	}
+}
+
+// formatMode 返回 ls 风格和八进制两种表示，例如 -rw-r--r-- (0644)
+func formatMode(mode os.FileMode) string {
+	perm := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		perm |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		perm |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		perm |= 01000
+	}
+	return fmt.Sprintf("%s (%04o)", mode.String(), perm)
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"fmt"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-units"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 基础镜像的识别方式
+const (
+	BaseMethodGiven   = "given"
+	BaseMethodTagged  = "tagged-ancestor"
+	BaseMethodUnknown = "unknown"
+)
+
+const DefaultLargestLayers = 5
+
+type HistoryOptions struct {
+	// Base 基础镜像引用，为空时尝试通过本地带标签的祖先镜像识别
+	Base string
+	// Sort created 与 docker history 一致按从新到旧，size 按大小从大到小
+	Sort    string
+	Largest int
+}
+
+// ImageHistory 分析镜像的每一层：创建指令、大小、时间，并区分基础镜像层和新增层
+func ImageHistory(ctx context.Context, cli *client.Client, imageRef string, opts HistoryOptions) (*resp.ImageHistory, error) {
+	if opts.Largest <= 0 {
+		opts.Largest = DefaultLargestLayers
+	}
+	inspect, err := cli.ImageInspect(ctx, imageRef)
+	if err != nil {
+		logs.ErrorWithFields("ImageInspect failed", map[string]interface{}{"image": imageRef, "error": err})
+		return nil, err
+	}
+	items, err := cli.ImageHistory(ctx, inspect.ID)
+	if err != nil {
+		logs.ErrorWithFields("ImageHistory failed", map[string]interface{}{"image": imageRef, "error": err})
+		return nil, err
+	}
+
+	// ImageHistory 返回从新到旧的顺序，baseCount 是最旧的若干层属于基础镜像
+	baseCount, method, baseImage := 0, BaseMethodUnknown, ""
+	if opts.Base != "" {
+		baseItems, err := cli.ImageHistory(ctx, opts.Base)
+		if err != nil {
+			return nil, fmt.Errorf("read history of base image %s failed: %v", opts.Base, err)
+		}
+		baseCount = commonHistoryPrefix(items, baseItems)
+		if baseCount == 0 {
+			return nil, fmt.Errorf("%s is not built on top of %s", imageRef, opts.Base)
+		}
+		method, baseImage = BaseMethodGiven, opts.Base
+	} else {
+		for i := 1; i < len(items); i++ {
+			if len(items[i].Tags) > 0 && items[i].ID != inspect.ID {
+				baseCount = len(items) - i
+				method, baseImage = BaseMethodTagged, items[i].Tags[0]
+				break
+			}
+		}
+	}
+
+	var total int64
+	for _, item := range items {
+		total += item.Size
+	}
+	now := time.Now()
+	result := &resp.ImageHistory{
+		Image:      imageRef,
+		ID:         inspect.ID,
+		TotalSize:  total,
+		TotalHuman: units.HumanSize(float64(total)),
+		Layers:     make([]resp.HistoryLayer, 0, len(items)),
+		Breakdown:  resp.HistoryBreakdown{BaseImage: baseImage, Method: method},
+	}
+	for i, item := range items {
+		created := time.Unix(item.Created, 0)
+		layer := resp.HistoryLayer{
+			Index:     len(items) - 1 - i,
+			CreatedBy: cleanCreatedBy(item.CreatedBy),
+			Created:   created.Format(time.RFC3339),
+			Age:       units.HumanDuration(now.Sub(created)) + " ago",
+			Size:      item.Size,
+			SizeHuman: units.HumanSize(float64(item.Size)),
+			Percent:   percent(item.Size, total),
+			Empty:     item.Size == 0,
+			Base:      i >= len(items)-baseCount,
+			Tags:      item.Tags,
+			Comment:   item.Comment,
+		}
+		if item.ID != "<missing>" {
+			layer.ID = item.ID
+		}
+		group := &result.Breakdown.Added
+		if layer.Base {
+			group = &result.Breakdown.Base
+		}
+		group.Layers++
+		group.Size += item.Size
+		result.Layers = append(result.Layers, layer)
+	}
+	for _, group := range []*resp.HistoryGroup{&result.Breakdown.Base, &result.Breakdown.Added} {
+		group.SizeHuman = units.HumanSize(float64(group.Size))
+		group.Percent = percent(group.Size, total)
+	}
+
+	largest := make([]resp.HistoryLayer, 0, len(result.Layers))
+	for _, layer := range result.Layers {
+		if !layer.Empty {
+			largest = append(largest, layer)
+		}
+	}
+	sort.SliceStable(largest, func(i, j int) bool { return largest[i].Size > largest[j].Size })
+	if len(largest) > opts.Largest {
+		largest = largest[:opts.Largest]
+	}
+	result.Largest = largest
+
+	switch opts.Sort {
+	case "", "created":
+	case "size":
+		sort.SliceStable(result.Layers, func(i, j int) bool { return result.Layers[i].Size > result.Layers[j].Size })
+	default:
+		return nil, fmt.Errorf("unsupported sort %q, expected created or size", opts.Sort)
+	}
+	return result, nil
+}
+
+// commonHistoryPrefix 从最旧的层开始比较，返回两个镜像共有的层数
+func commonHistoryPrefix(items, base []image.HistoryResponseItem) int {
+	n := 0
+	for n < len(items) && n < len(base) {
+		a, b := items[len(items)-1-n], base[len(base)-1-n]
+		if a.CreatedBy != b.CreatedBy || a.Created != b.Created || a.Size != b.Size {
+			break
+		}
+		n++
+	}
+	// 基础镜像的每一层都必须匹配
+	if n != len(base) {
+		return 0
+	}
+	return n
+}
+
+// cleanCreatedBy 去掉经典构建器加在指令前的 shell 前缀
+func cleanCreatedBy(createdBy string) string {
+	createdBy = strings.TrimPrefix(createdBy, "/bin/sh -c #(nop) ")
+	// 带构建参数的 RUN 形如 |2 A=1 B=2 /bin/sh -c ...
+	if strings.HasPrefix(createdBy, "|") {
+		if idx := strings.Index(createdBy, "/bin/sh -c "); idx > 0 {
+			createdBy = createdBy[idx:]
+		}
+	}
+	if strings.HasPrefix(createdBy, "/bin/sh -c ") {
+		createdBy = "RUN " + strings.TrimPrefix(createdBy, "/bin/sh -c ")
+	}
+	return strings.TrimSpace(createdBy)
+}
+
+func percent(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return round2(float64(part) / float64(total) * 100)
+}
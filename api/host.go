@@ -0,0 +1,16 @@
+package api
+
+import "net"
+
+// isLoopbackHost 判断主机名（可以带端口）是否指向本机回环地址
+func isLoopbackHost(host string) bool {
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	if hostname == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.IsLoopback()
+}
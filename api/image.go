@@ -3,53 +3,121 @@ package api
 import (
 	"context"
 	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
 	"encoding/json"
 	"fmt"
+	"github.com/distribution/reference"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"io"
+	"strings"
 )
 
-func PullImage(ctx context.Context, cli *client.Client, name string) ([]jsonmessage.JSONMessage, error) {
-	logs.InfoWithFields("Start pulling image", map[string]interface{}{"image": name})
-	stream, err := cli.ImagePull(ctx, name, image.PullOptions{})
+// PullOptions 拉取选项，Platform 形如 linux/arm64
+type PullOptions struct {
+	Platform string
+	AllTags  bool
+}
+
+// PullImage 拉取镜像并按层汇总进度，onProgress 在每条层进度消息后调用
+func PullImage(ctx context.Context, cli *client.Client, name string, opts PullOptions, onProgress func(*LayerProgress)) (*resp.ImagePull, error) {
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image reference %q: %v", name, err)
+	}
+	if opts.AllTags {
+		if !reference.IsNameOnly(named) {
+			return nil, fmt.Errorf("tag can't be used with allTags")
+		}
+	} else {
+		named = reference.TagNameOnly(named)
+	}
+	ref := reference.FamiliarString(named)
+	auth, err := Credentials.EncodedAuth(ref)
 	if err != nil {
-		logs.ErrorWithFields("ImagePull failed", map[string]interface{}{"image": name, "error": err})
+		return nil, err
+	}
+	logs.InfoWithFields("Start pulling image", map[string]interface{}{"image": ref, "platform": opts.Platform, "allTags": opts.AllTags, "auth": auth != ""})
+	stream, err := cli.ImagePull(ctx, ref, image.PullOptions{All: opts.AllTags, Platform: opts.Platform, RegistryAuth: auth})
+	if err != nil {
+		logs.ErrorWithFields("ImagePull failed", map[string]interface{}{"image": ref, "error": err})
 		return nil, err
 	}
 	defer stream.Close()
 
+	result := &resp.ImagePull{Reference: ref, Platform: opts.Platform}
+	progress := NewLayerProgress()
 	decoder := json.NewDecoder(stream)
-	var allMessages []jsonmessage.JSONMessage
-	var pullSuccess = true
-
+	var current *resp.PulledTag
 	for {
 		var msg jsonmessage.JSONMessage
 		if err := decoder.Decode(&msg); err != nil {
 			if err == io.EOF {
-				logs.InfoWithFields("Image pull stream EOF", map[string]interface{}{"image": name})
 				break
 			}
-			logs.ErrorWithFields("Decode image pull message failed", map[string]interface{}{"image": name, "error": err})
+			logs.ErrorWithFields("Decode image pull message failed", map[string]interface{}{"image": ref, "error": err})
 			return nil, fmt.Errorf("解析消息失败: %v", err)
 		}
-		// 保存消息
-		allMessages = append(allMessages, msg)
-		// 检查错误
 		if msg.Error != nil {
-			pullSuccess = false
-			logs.ErrorWithFields("Image pull error message", map[string]interface{}{"image": name, "error": msg.Error})
+			logs.ErrorWithFields("Image pull error message", map[string]interface{}{"image": ref, "error": msg.Error})
+			return nil, fmt.Errorf("pull %s failed: %s", ref, msg.Error.Message)
 		}
-		logs.DebugWithFields("Image pull message", map[string]interface{}{"image": name, "msg": msg})
+		switch {
+		// 每个标签以 Pulling from 开始，消息ID为标签名
+		case strings.HasPrefix(msg.Status, "Pulling from"):
+			result.Tags = append(result.Tags, resp.PulledTag{Tag: msg.ID})
+			current = &result.Tags[len(result.Tags)-1]
+		case strings.HasPrefix(msg.Status, "Digest: "):
+			if current != nil {
+				current.Digest = strings.TrimPrefix(msg.Status, "Digest: ")
+			}
+		case strings.HasPrefix(msg.Status, "Status: "):
+			if current != nil {
+				current.Status = strings.TrimPrefix(msg.Status, "Status: ")
+			}
+		default:
+			progress.Update(msg)
+			if onProgress != nil && msg.ID != "" {
+				onProgress(progress)
+			}
+		}
+	}
+	// 单个标签时摘要和状态直接放在顶层
+	if len(result.Tags) == 1 && !opts.AllTags {
+		result.Digest, result.Status = result.Tags[0].Digest, result.Tags[0].Status
+		result.Tags = nil
 	}
-	// 拉取完成后，检查结果
-	if !pullSuccess {
-		logs.ErrorWithFields("Image pull finished with error", map[string]interface{}{"image": name})
-		return nil, fmt.Errorf("镜像拉取过程中发生错误，请检查日志")
+	result.Layers = progress.Layers()
+	_, result.Downloaded, result.LayersDone, result.LayerCount = progress.Totals()
+	logs.InfoWithFields("Image pull finished successfully", map[string]interface{}{"image": ref, "digest": result.Digest, "layers": result.LayerCount})
+	return result, nil
+}
+
+// 拉取策略
+const (
+	PullAlways  = "always"
+	PullMissing = "missing"
+	PullNever   = "never"
+)
+
+// EnsureImage 按拉取策略准备镜像，没有发生拉取时返回 nil
+func EnsureImage(ctx context.Context, cli *client.Client, name, policy string) (*resp.ImagePull, error) {
+	switch policy {
+	case "", PullAlways:
+		return PullImage(ctx, cli, name, PullOptions{}, nil)
+	case PullMissing:
+		if _, err := cli.ImageInspect(ctx, name); err == nil {
+			return nil, nil
+		} else if !client.IsErrNotFound(err) {
+			return nil, err
+		}
+		return PullImage(ctx, cli, name, PullOptions{}, nil)
+	case PullNever:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported pull policy %q", policy)
 	}
-	logs.InfoWithFields("Image pull finished successfully", map[string]interface{}{"image": name})
-	return allMessages, nil
 }
 
 func Rmi(ctx context.Context, cli *client.Client, imageName string) ([]image.DeleteResponse, error) {
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"io"
+	"os"
+	"strings"
+)
+
+// SaveImages 把一个或多个镜像保存为 docker save 格式的归档，结果中附带每个镜像的ID和摘要
+func SaveImages(ctx context.Context, cli *client.Client, refs []string, dir string, opts ArchiveOptions) (*resp.ImageArchive, error) {
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("at least one image is required")
+	}
+	// 先确认镜像都存在，避免写出半个归档
+	images := make([]resp.ArchivedImage, 0, len(refs))
+	for _, ref := range refs {
+		inspect, err := cli.ImageInspect(ctx, ref)
+		if err != nil {
+			logs.ErrorWithFields("ImageInspect failed", map[string]interface{}{"image": ref, "error": err})
+			return nil, err
+		}
+		images = append(images, resp.ArchivedImage{
+			Reference:   ref,
+			ID:          inspect.ID,
+			RepoTags:    inspect.RepoTags,
+			RepoDigests: inspect.RepoDigests,
+			Size:        inspect.Size,
+		})
+	}
+	if opts.Path == "" {
+		opts.Path = archiveName(strings.ReplaceAll(refs[0], ":", "_"), opts.Compression)
+	}
+	reader, err := cli.ImageSave(ctx, refs)
+	if err != nil {
+		logs.ErrorWithFields("ImageSave failed", map[string]interface{}{"images": refs, "error": err})
+		return nil, err
+	}
+	defer reader.Close()
+	archive, err := WriteArchive(dir, reader, opts)
+	if err != nil {
+		logs.ErrorWithFields("WriteArchive failed", map[string]interface{}{"images": refs, "error": err})
+		return nil, err
+	}
+	logs.InfoWithFields("ImageSave success", map[string]interface{}{"images": refs, "path": archive.Path, "size": archive.Size})
+	return &resp.ImageArchive{Archive: *archive, Images: images}, nil
+}
+
+// LoadImages 从归档目录中读取 docker save 归档并导入，gzip 压缩的归档由守护进程自动解压
+func LoadImages(ctx context.Context, cli *client.Client, dir, path string) (*resp.ImageArchive, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	target, err := ResolveArchivePath(dir, path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(target)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	compression := CompressionNone
+	magic := make([]byte, 2)
+	if n, _ := io.ReadFull(file, magic); n == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		compression = CompressionGzip
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	digest := sha256.New()
+	counter := &countingWriter{w: digest}
+	load, err := cli.ImageLoad(ctx, io.TeeReader(file, counter), client.ImageLoadWithQuiet(true))
+	if err != nil {
+		logs.ErrorWithFields("ImageLoad failed", map[string]interface{}{"path": target, "error": err})
+		return nil, err
+	}
+	defer load.Body.Close()
+
+	var loaded []string
+	decoder := json.NewDecoder(load.Body)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("decode load message failed: %v", err)
+		}
+		if msg.Error != nil {
+			return nil, fmt.Errorf("load %s failed: %s", target, msg.Error.Message)
+		}
+		// 输出形如 Loaded image: nginx:latest 或 Loaded image ID: sha256:...
+		for _, line := range strings.Split(msg.Stream, "\n") {
+			for _, prefix := range []string{"Loaded image ID: ", "Loaded image: "} {
+				if ref, ok := strings.CutPrefix(strings.TrimSpace(line), prefix); ok {
+					loaded = append(loaded, ref)
+					break
+				}
+			}
+		}
+	}
+	result := &resp.ImageArchive{
+		Archive: resp.Archive{
+			Path:        target,
+			Size:        counter.n,
+			Digest:      "sha256:" + hex.EncodeToString(digest.Sum(nil)),
+			Compression: compression,
+		},
+		Images: make([]resp.ArchivedImage, 0, len(loaded)),
+	}
+	for _, ref := range loaded {
+		img := resp.ArchivedImage{Reference: ref}
+		if inspect, err := cli.ImageInspect(ctx, ref); err == nil {
+			img.ID, img.RepoTags, img.RepoDigests, img.Size = inspect.ID, inspect.RepoTags, inspect.RepoDigests, inspect.Size
+		}
+		result.Images = append(result.Images, img)
+	}
+	logs.InfoWithFields("ImageLoad success", map[string]interface{}{"path": target, "images": loaded})
+	return result, nil
+}
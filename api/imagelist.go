@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"fmt"
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ImageListOptions 对应 docker image ls 的过滤、排序和数量限制
+type ImageListOptions struct {
+	All       *bool    `json:"all,omitempty"`
+	Reference []string `json:"reference,omitempty"`
+	Label     []string `json:"label,omitempty"`
+	Dangling  *bool    `json:"dangling,omitempty"`
+	Before    string   `json:"before,omitempty"`
+	Since     string   `json:"since,omitempty"`
+	Sort      string   `json:"sort,omitempty"`
+	Order     string   `json:"order,omitempty"`
+	Limit     int      `json:"limit,omitempty"`
+}
+
+// ListImages 列出本地镜像，每个 repo:tag 一条记录，没有标签的镜像单独一条
+func ListImages(ctx context.Context, cli *client.Client, opts ImageListOptions) ([]resp.Image, error) {
+	args := filters.NewArgs()
+	for _, ref := range opts.Reference {
+		args.Add("reference", ref)
+	}
+	for _, label := range opts.Label {
+		args.Add("label", label)
+	}
+	if opts.Dangling != nil {
+		args.Add("dangling", strconv.FormatBool(*opts.Dangling))
+	}
+	if opts.Before != "" {
+		args.Add("before", opts.Before)
+	}
+	if opts.Since != "" {
+		args.Add("since", opts.Since)
+	}
+	all := true
+	if opts.All != nil {
+		all = *opts.All
+	}
+	list, err := cli.ImageList(ctx, image.ListOptions{All: all, Filters: args})
+	if err != nil {
+		logs.ErrorWithFields("ImageList failed", map[string]interface{}{"error": err})
+		return nil, err
+	}
+
+	// 经典构建器留下的中间层以父镜像的形式被其他镜像引用，与 docker image ls -f dangling=true 一样不算悬空
+	parents := make(map[string]bool)
+	for _, summary := range list {
+		if summary.ParentID != "" {
+			parents[summary.ParentID] = true
+		}
+	}
+	images := make([]resp.Image, 0, len(list))
+	for _, summary := range list {
+		for _, entry := range imageEntries(summary, parents[summary.ID]) {
+			// 守护进程的 dangling 过滤在 all=true 时也会返回中间层
+			if entry.Intermediate && opts.Dangling != nil && *opts.Dangling {
+				continue
+			}
+			images = append(images, entry)
+		}
+	}
+	if err := sortImages(images, opts.Sort, opts.Order); err != nil {
+		return nil, err
+	}
+	if opts.Limit > 0 && len(images) > opts.Limit {
+		images = images[:opts.Limit]
+	}
+	return images, nil
+}
+
+// imageEntries 把一个镜像按标签展开，只有摘要的镜像按摘要所在仓库展开，parent 表示它是其他镜像的父镜像
+func imageEntries(summary image.Summary, parent bool) []resp.Image {
+	base := resp.Image{
+		ImageID:    summary.ID,
+		Created:    summary.Created,
+		Size:       summary.Size,
+		Containers: summary.Containers,
+		Labels:     summary.Labels,
+	}
+	// 按仓库归类摘要，每条记录只带自己仓库的摘要
+	digests := make(map[string][]string)
+	var digestRepos []reference.Named
+	for _, rd := range summary.RepoDigests {
+		named, err := reference.ParseNormalizedNamed(rd)
+		if err != nil {
+			continue
+		}
+		name := named.Name()
+		if _, ok := digests[name]; !ok {
+			digestRepos = append(digestRepos, reference.TrimNamed(named))
+		}
+		digests[name] = append(digests[name], rd)
+	}
+
+	var entries []resp.Image
+	for _, tag := range summary.RepoTags {
+		named, err := reference.ParseNormalizedNamed(tag)
+		if err != nil {
+			// <none>:<none> 等无法解析的标签忽略
+			continue
+		}
+		entry := withReference(base, named)
+		entry.RepoDigests = digests[named.Name()]
+		entries = append(entries, entry)
+	}
+	if len(entries) > 0 {
+		return entries
+	}
+	for _, named := range digestRepos {
+		entry := withReference(base, named)
+		entry.RepoDigests = digests[named.Name()]
+		entries = append(entries, entry)
+	}
+	if len(entries) > 0 {
+		return entries
+	}
+	if parent {
+		base.Intermediate = true
+	} else {
+		base.Dangling = true
+	}
+	return []resp.Image{base}
+}
+
+func withReference(entry resp.Image, named reference.Named) resp.Image {
+	entry.Reference = reference.FamiliarString(named)
+	entry.Registry = reference.Domain(named)
+	// 仓库只保留路径部分，Docker Hub 官方镜像省略 library/
+	entry.Repository = reference.Path(named)
+	if entry.Registry == DockerHubHost {
+		entry.Repository = strings.TrimPrefix(entry.Repository, "library/")
+	}
+	if tagged, ok := named.(reference.Tagged); ok {
+		entry.Tag = tagged.Tag()
+	}
+	return entry
+}
+
+// sortImages 默认按创建时间倒序，与 docker image ls 一致
+func sortImages(images []resp.Image, by, order string) error {
+	if by == "" {
+		by = "created"
+	}
+	var less func(a, b resp.Image) bool
+	switch by {
+	case "created":
+		less = func(a, b resp.Image) bool { return a.Created < b.Created }
+	case "size":
+		less = func(a, b resp.Image) bool { return a.Size < b.Size }
+	case "reference":
+		less = func(a, b resp.Image) bool { return a.Reference < b.Reference }
+	default:
+		return fmt.Errorf("unsupported sort %q, expected created, size or reference", by)
+	}
+	if order == "" {
+		order = "desc"
+		if by == "reference" {
+			order = "asc"
+		}
+	}
+	switch order {
+	case "asc":
+	case "desc":
+		asc := less
+		less = func(a, b resp.Image) bool { return asc(b, a) }
+	default:
+		return fmt.Errorf("unsupported order %q, expected asc or desc", order)
+	}
+	sort.SliceStable(images, func(i, j int) bool { return less(images[i], images[j]) })
+	return nil
+}
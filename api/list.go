@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"encoding/json"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContainerListOptions 对应 docker ps 的过滤、排序和数量限制
+type ContainerListOptions struct {
+	All      *bool    `json:"all,omitempty"`
+	Status   []string `json:"status,omitempty"`
+	Label    []string `json:"label,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Ancestor string   `json:"ancestor,omitempty"`
+	Network  string   `json:"network,omitempty"`
+	Health   string   `json:"health,omitempty"`
+	Exited   *int     `json:"exited,omitempty"`
+	Sort     string   `json:"sort,omitempty"`
+	Order    string   `json:"order,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
+}
+
+// ListContainers 按过滤条件列出容器，排序后再截断到 Limit
+func ListContainers(ctx context.Context, cli *client.Client, opts ContainerListOptions) ([]resp.Container, error) {
+	args := filters.NewArgs()
+	for _, status := range opts.Status {
+		args.Add("status", status)
+	}
+	for _, label := range opts.Label {
+		args.Add("label", label)
+	}
+	if opts.Name != "" {
+		args.Add("name", opts.Name)
+	}
+	if opts.Ancestor != "" {
+		args.Add("ancestor", opts.Ancestor)
+	}
+	if opts.Network != "" {
+		args.Add("network", opts.Network)
+	}
+	if opts.Health != "" {
+		args.Add("health", opts.Health)
+	}
+	if opts.Exited != nil {
+		args.Add("exited", strconv.Itoa(*opts.Exited))
+	}
+	all := true
+	if opts.All != nil {
+		all = *opts.All
+	}
+	list, err := cli.ContainerList(ctx, container.ListOptions{All: all, Filters: args})
+	if err != nil {
+		logs.ErrorWithFields("ContainerList failed", map[string]interface{}{"error": err})
+		return nil, err
+	}
+
+	containers := make([]resp.Container, 0, len(list))
+	for _, ctr := range list {
+		networks := make(map[string]string)
+		if ctr.NetworkSettings != nil {
+			for name, endpoint := range ctr.NetworkSettings.Networks {
+				ip := ""
+				if endpoint != nil {
+					ip = endpoint.IPAddress
+				}
+				networks[name] = ip
+			}
+		}
+		containers = append(containers, resp.Container{
+			ID:       ctr.ID,
+			Names:    ctr.Names,
+			Command:  ctr.Command,
+			Created:  ctr.Created,
+			Ports:    ctr.Ports,
+			Image:    ctr.Image,
+			State:    ctr.State,
+			Status:   ctr.Status,
+			Labels:   ctr.Labels,
+			Mounts:   ctr.Mounts,
+			Networks: networks,
+		})
+	}
+	if err := sortContainers(containers, opts.Sort, opts.Order); err != nil {
+		return nil, err
+	}
+	if opts.Limit > 0 && len(containers) > opts.Limit {
+		containers = containers[:opts.Limit]
+	}
+	return containers, nil
+}
+
+// sortContainers 默认按创建时间倒序，与 docker ps 一致
+func sortContainers(containers []resp.Container, by, order string) error {
+	if by == "" {
+		by = "created"
+	}
+	var less func(a, b resp.Container) bool
+	switch by {
+	case "created":
+		less = func(a, b resp.Container) bool { return a.Created < b.Created }
+		if order == "" {
+			order = "desc"
+		}
+	case "name":
+		less = func(a, b resp.Container) bool { return firstName(a) < firstName(b) }
+	case "image":
+		less = func(a, b resp.Container) bool { return a.Image < b.Image }
+	case "state":
+		less = func(a, b resp.Container) bool { return a.State < b.State }
+	default:
+		return fmt.Errorf("unsupported sort %q, expected created, name, image or state", by)
+	}
+	switch order {
+	case "", "asc":
+	case "desc":
+		asc := less
+		less = func(a, b resp.Container) bool { return asc(b, a) }
+	default:
+		return fmt.Errorf("unsupported order %q, expected asc or desc", order)
+	}
+	sort.SliceStable(containers, func(i, j int) bool { return less(containers[i], containers[j]) })
+	return nil
+}
+
+func firstName(c resp.Container) string {
+	if len(c.Names) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// ProjectFields 只保留指定的字段，字段名与 JSON 键匹配且不区分大小写
+func ProjectFields(items interface{}, fields []string) ([]map[string]interface{}, error) {
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(raw, &objects); err != nil {
+		return nil, err
+	}
+	// 按元素类型校验字段名，列表为空时同样会报告拼错的字段
+	itemsType := reflect.TypeOf(items)
+	if itemsType == nil || itemsType.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("field projection needs a list, got %T", items)
+	}
+	valid := jsonFieldNames(itemsType.Elem())
+	known := make(map[string]bool, len(valid))
+	for _, name := range valid {
+		known[strings.ToLower(name)] = true
+	}
+	wanted := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if !known[strings.ToLower(f)] {
+			return nil, fmt.Errorf("unknown field %q, available fields: %s", f, strings.Join(valid, ", "))
+		}
+		wanted[strings.ToLower(f)] = true
+	}
+	for _, obj := range objects {
+		for key := range obj {
+			if !wanted[strings.ToLower(key)] {
+				delete(obj, key)
+			}
+		}
+	}
+	return objects, nil
+}
+
+// jsonFieldNames 返回结构体序列化后的 JSON 键名，包含嵌入结构体的字段
+func jsonFieldNames(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && name == "" {
+			names = append(names, jsonFieldNames(field.Type)...)
+			continue
+		}
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"docker-mcp/resp"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"strings"
+)
+
+// LayerProgress 汇总拉取、推送过程中每个层的进度
+type LayerProgress struct {
+	layers map[string]*resp.LayerStatus
+	order  []string
+	// transferred 每个层已下载或上传的字节数。拉取时 Extracting 会让 Current 从 0 重新计数，
+	// 所以传输阶段单独记录，保证 Totals 返回的字节数不回退
+	transferred map[string]*layerBytes
+}
+
+type layerBytes struct {
+	current, total int64
+}
+
+func NewLayerProgress() *LayerProgress {
+	return &LayerProgress{layers: make(map[string]*resp.LayerStatus), transferred: make(map[string]*layerBytes)}
+}
+
+// Update 记录一条进度消息，只处理带层ID的消息
+func (p *LayerProgress) Update(msg jsonmessage.JSONMessage) {
+	if msg.ID == "" || msg.Status == "" {
+		return
+	}
+	// 拉取时带 tag 的 Pulling from 消息的ID是标签而不是层
+	if strings.HasPrefix(msg.Status, "Pulling from") || strings.HasPrefix(msg.Status, "The push refers to") {
+		return
+	}
+	layer, ok := p.layers[msg.ID]
+	if !ok {
+		layer = &resp.LayerStatus{ID: msg.ID}
+		p.layers[msg.ID] = layer
+		p.order = append(p.order, msg.ID)
+		p.transferred[msg.ID] = &layerBytes{}
+	}
+	moved := p.transferred[msg.ID]
+	switch {
+	case msg.Status == "Downloading" || msg.Status == "Pushing":
+		if msg.Progress != nil && msg.Progress.Total > 0 {
+			moved.total = msg.Progress.Total
+			moved.current = max(moved.current, min(msg.Progress.Current, msg.Progress.Total))
+		}
+	case msg.Status == "Verifying Checksum" || msg.Status == "Download complete" || msg.Status == "Extracting" || isLayerDone(msg.Status):
+		moved.current = moved.total
+	}
+	layer.Status = msg.Status
+	if msg.Progress != nil && msg.Progress.Total > 0 {
+		layer.Current = msg.Progress.Current
+		layer.Total = msg.Progress.Total
+	} else if layer.Total > 0 && isLayerDone(msg.Status) {
+		layer.Current = layer.Total
+	}
+}
+
+// Totals 返回已传输字节数、已知的总字节数，以及已完成和全部的层数。解压阶段不计入字节数
+func (p *LayerProgress) Totals() (current, total int64, done, count int) {
+	for _, id := range p.order {
+		moved := p.transferred[id]
+		current += moved.current
+		total += moved.total
+		if isLayerDone(p.layers[id].Status) {
+			done++
+		}
+	}
+	return current, total, done, len(p.order)
+}
+
+func (p *LayerProgress) Layers() []resp.LayerStatus {
+	layers := make([]resp.LayerStatus, 0, len(p.order))
+	for _, id := range p.order {
+		layers = append(layers, *p.layers[id])
+	}
+	return layers
+}
+
+func isLayerDone(status string) bool {
+	switch {
+	case status == "Pushed", status == "Pull complete", status == "Already exists",
+		status == "Layer already exists",
+		strings.HasPrefix(status, "Mounted from"):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-units"
+	"regexp"
+	"strings"
+)
+
+type ImagePruneOptions struct {
+	// All 删除所有未被容器使用的镜像，否则只删除悬空镜像
+	All bool
+	// Until 只删除早于该时间创建的镜像，支持时间戳或 24h 这样的时长
+	Until string
+	// Label 标签过滤，label!=key=value 形式表示排除
+	Label []string
+}
+
+// PruneImages 清理未使用的镜像，返回删除的镜像和回收的空间
+func PruneImages(ctx context.Context, cli *client.Client, opts ImagePruneOptions) (*resp.ImagePrune, error) {
+	args := filters.NewArgs()
+	if opts.All {
+		args.Add("dangling", "false")
+	} else {
+		args.Add("dangling", "true")
+	}
+	if opts.Until != "" {
+		args.Add("until", opts.Until)
+	}
+	for _, label := range opts.Label {
+		if negated, ok := strings.CutPrefix(label, "label!="); ok {
+			args.Add("label!", negated)
+		} else if negated, ok := strings.CutPrefix(label, "!"); ok {
+			args.Add("label!", negated)
+		} else {
+			args.Add("label", strings.TrimPrefix(label, "label="))
+		}
+	}
+	report, err := cli.ImagesPrune(ctx, args)
+	if err != nil {
+		logs.ErrorWithFields("ImagesPrune failed", map[string]interface{}{"error": err})
+		return nil, err
+	}
+	result := &resp.ImagePrune{
+		Deleted:             []string{},
+		Untagged:            []string{},
+		SpaceReclaimed:      report.SpaceReclaimed,
+		SpaceReclaimedHuman: units.HumanSize(float64(report.SpaceReclaimed)),
+	}
+	for _, d := range report.ImagesDeleted {
+		if d.Deleted != "" {
+			result.Deleted = append(result.Deleted, d.Deleted)
+		}
+		if d.Untagged != "" {
+			result.Untagged = append(result.Untagged, d.Untagged)
+		}
+	}
+	logs.InfoWithFields("ImagesPrune success", map[string]interface{}{"deleted": len(result.Deleted), "spaceReclaimed": report.SpaceReclaimed})
+	return result, nil
+}
+
+// RemoveImages 逐个删除镜像，单个失败不影响其余镜像，结果逐个返回
+func RemoveImages(ctx context.Context, cli *client.Client, refs []string, force bool) []resp.ImageRemove {
+	results := make([]resp.ImageRemove, 0, len(refs))
+	// redis 和 redis:latest 是同一个镜像，重复删除第二个会报 No such image
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		key := imageRefKey(ref)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result := resp.ImageRemove{Image: ref}
+		responses, err := cli.ImageRemove(ctx, ref, image.RemoveOptions{Force: force, PruneChildren: true})
+		if err != nil {
+			logs.ErrorWithFields("Remove image failed", map[string]interface{}{"image": ref, "error": err})
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Removed = true
+		for _, r := range responses {
+			if r.Untagged != "" {
+				result.Untagged = append(result.Untagged, r.Untagged)
+			}
+			if r.Deleted != "" {
+				result.Deleted = append(result.Deleted, r.Deleted)
+			}
+		}
+		logs.InfoWithFields("Remove image success", map[string]interface{}{"image": ref})
+		results = append(results, result)
+	}
+	return results
+}
+
+// 镜像ID或ID前缀，去重时保持原样
+var imageIDPattern = regexp.MustCompile(`^(sha256:)?[a-f0-9]{4,64}$`)
+
+// imageRefKey 把镜像引用规范化为带标签的完整名称，用于去重，ID 保持原样
+func imageRefKey(ref string) string {
+	if imageIDPattern.MatchString(ref) {
+		return ref
+	}
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ref
+	}
+	return reference.TagNameOnly(named).String()
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"encoding/json"
+	"fmt"
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"io"
+)
+
+// PushImage 推送镜像到仓库，自动使用凭据存储中的认证信息，onProgress 在每条层进度消息后调用
+func PushImage(ctx context.Context, cli *client.Client, imageRef string, allTags bool, onProgress func(*LayerProgress)) (*resp.ImagePush, error) {
+	named, err := reference.ParseNormalizedNamed(imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image reference %q: %v", imageRef, err)
+	}
+	if allTags {
+		if _, ok := named.(reference.Tagged); ok {
+			return nil, fmt.Errorf("tag can't be used with allTags")
+		}
+	} else {
+		named = reference.TagNameOnly(named)
+	}
+	ref := reference.FamiliarString(named)
+	auth, err := Credentials.EncodedAuth(ref)
+	if err != nil {
+		return nil, err
+	}
+	logs.InfoWithFields("Start pushing image", map[string]interface{}{"image": ref, "allTags": allTags, "auth": auth != ""})
+	stream, err := cli.ImagePush(ctx, ref, image.PushOptions{All: allTags, RegistryAuth: auth})
+	if err != nil {
+		logs.ErrorWithFields("ImagePush failed", map[string]interface{}{"image": ref, "error": err})
+		return nil, err
+	}
+	defer stream.Close()
+
+	result := &resp.ImagePush{Reference: ref}
+	progress := NewLayerProgress()
+	decoder := json.NewDecoder(stream)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("decode push message failed: %v", err)
+		}
+		if msg.Error != nil {
+			logs.ErrorWithFields("Image push error message", map[string]interface{}{"image": ref, "error": msg.Error})
+			return nil, fmt.Errorf("push %s failed: %s", ref, msg.Error.Message)
+		}
+		// 推送完成后的 Aux 消息包含最终的摘要
+		if msg.Aux != nil {
+			var aux struct {
+				Tag    string
+				Digest string
+				Size   int64
+			}
+			if json.Unmarshal(*msg.Aux, &aux) == nil && aux.Digest != "" {
+				result.Tag, result.Digest, result.Size = aux.Tag, aux.Digest, aux.Size
+			}
+			continue
+		}
+		progress.Update(msg)
+		if onProgress != nil && msg.ID != "" {
+			onProgress(progress)
+		}
+	}
+	result.Layers = progress.Layers()
+	logs.InfoWithFields("Image push finished successfully", map[string]interface{}{"image": ref, "digest": result.Digest})
+	return result, nil
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"time"
+)
+
+// DefaultHealthTimeout 等待新容器健康检查通过的默认时间
+const DefaultHealthTimeout = 60 * time.Second
+
+// 没有健康检查的容器，启动后保持运行这么久才视为成功
+const startupGracePeriod = 5 * time.Second
+
+// rollbackTimeout 回滚操作的超时时间，与请求的 ctx 无关
+const rollbackTimeout = 60 * time.Second
+
+type RecreateOptions struct {
+	// Image 新镜像，为空时使用原容器的镜像引用（重新拉取同一个标签）
+	Image string
+	// Pull 拉取策略，默认 always
+	Pull string
+	// StopTimeout 停止旧容器的超时秒数，nil 时使用 Docker 默认值
+	StopTimeout *int
+	// HealthTimeout 等待新容器健康的时间
+	HealthTimeout time.Duration
+	// KeepOld 成功后保留改名后的旧容器
+	KeepOld bool
+}
+
+// RecreateContainer 用新镜像重建容器，直接复制原容器的 Config 和 HostConfig，只替换镜像；新容器健康检查失败时回滚到旧容器。
+// 失败时同时返回 result 和 error，result 中的 RolledBack、Reason 说明回滚情况
+func RecreateContainer(ctx context.Context, cli *client.Client, containerID string, opts RecreateOptions) (*resp.ContainerRecreate, error) {
+	replica, inspect, err := InspectReplica(ctx, cli, containerID)
+	if err != nil {
+		return nil, err
+	}
+	if replica.HostConfig.AutoRemove {
+		return nil, fmt.Errorf("container %s was started with autoRemove; stopping it would delete it, so it cannot be recreated safely", replica.Name)
+	}
+	if opts.Image == "" {
+		opts.Image = inspect.Config.Image
+	}
+	if opts.HealthTimeout <= 0 {
+		opts.HealthTimeout = DefaultHealthTimeout
+	}
+	result := &resp.ContainerRecreate{
+		Name:     replica.Name,
+		OldID:    inspect.ID,
+		OldImage: inspect.Config.Image,
+		NewImage: opts.Image,
+	}
+	if _, err := EnsureImage(ctx, cli, opts.Image, opts.Pull); err != nil {
+		return nil, err
+	}
+	replica.Config.Image = opts.Image
+	wasRunning := inspect.State != nil && inspect.State.Running
+
+	if wasRunning {
+		if err := cli.ContainerStop(ctx, inspect.ID, container.StopOptions{Timeout: opts.StopTimeout}); err != nil {
+			logs.ErrorWithFields("ContainerStop failed", map[string]interface{}{"id": inspect.ID, "error": err})
+			return nil, err
+		}
+	}
+	oldName := fmt.Sprintf("%s_old_%d", replica.Name, time.Now().Unix())
+	if err := cli.ContainerRename(ctx, inspect.ID, oldName); err != nil {
+		logs.ErrorWithFields("ContainerRename failed", map[string]interface{}{"id": inspect.ID, "error": err})
+		restoreOld(ctx, cli, inspect.ID, "", "", wasRunning)
+		return nil, err
+	}
+
+	create, err := CreateReplica(ctx, cli, replica)
+	if err != nil {
+		restoreOld(ctx, cli, inspect.ID, "", replica.Name, wasRunning)
+		result.RolledBack = true
+		result.Reason = fmt.Sprintf("create failed: %v", err)
+		return result, fmt.Errorf("recreate %s failed and was rolled back: %s", replica.Name, result.Reason)
+	}
+	result.ID = create.ID
+	result.Warnings = create.Warnings
+
+	health, err := startAndWait(ctx, cli, create.ID, opts.HealthTimeout)
+	result.Health = health
+	if err != nil {
+		logs.WarnWithFields("New container is not healthy, rolling back", map[string]interface{}{"id": create.ID, "error": err})
+		restoreOld(ctx, cli, inspect.ID, create.ID, replica.Name, wasRunning)
+		result.RolledBack = true
+		result.Reason = err.Error()
+		return result, fmt.Errorf("recreate %s failed and was rolled back: %s", replica.Name, result.Reason)
+	}
+
+	if opts.KeepOld {
+		result.OldName = oldName
+	} else if err := cli.ContainerRemove(ctx, inspect.ID, container.RemoveOptions{}); err != nil {
+		logs.WarnWithFields("Remove old container failed", map[string]interface{}{"id": inspect.ID, "error": err})
+		result.OldName = oldName
+	} else {
+		result.OldRemoved = true
+	}
+	logs.InfoWithFields("RecreateContainer success", map[string]interface{}{"name": replica.Name, "id": create.ID, "image": opts.Image})
+	return result, nil
+}
+
+// startAndWait 启动容器并等待其健康；没有健康检查时要求在宽限期内保持运行
+func startAndWait(ctx context.Context, cli *client.Client, containerID string, timeout time.Duration) (string, error) {
+	if err := cli.ContainerStart(ctx, containerID, container.StartOptions{}); err != nil {
+		return "", fmt.Errorf("start failed: %v", err)
+	}
+	deadline := time.Now().Add(timeout)
+	started := time.Now()
+	for {
+		inspect, err := cli.ContainerInspect(ctx, containerID)
+		if err != nil {
+			return "", err
+		}
+		state := inspect.State
+		if state == nil {
+			return "", fmt.Errorf("container has no state")
+		}
+		if !state.Running || state.Restarting {
+			return state.Status, fmt.Errorf("container is %s (exit code %d) %s", state.Status, state.ExitCode, state.Error)
+		}
+		if state.Health != nil && state.Health.Status != container.NoHealthcheck {
+			switch state.Health.Status {
+			case container.Healthy:
+				return container.Healthy, nil
+			case container.Unhealthy:
+				return container.Unhealthy, fmt.Errorf("healthcheck failed %d times in a row", state.Health.FailingStreak)
+			}
+		} else if time.Since(started) >= startupGracePeriod {
+			return state.Status, nil
+		}
+		if time.Now().After(deadline) {
+			return state.Status, fmt.Errorf("container did not become healthy within %s", timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return state.Status, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// restoreOld 删除失败的新容器，把旧容器改回原名并按原状态启动。
+// 回滚不使用请求的 ctx，客户端取消或超时后也要执行完，否则旧容器会停留在 *_old_* 名称下
+func restoreOld(ctx context.Context, cli *client.Client, containerID, failedID, name string, start bool) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+	if failedID != "" {
+		if err := cli.ContainerRemove(ctx, failedID, container.RemoveOptions{Force: true}); err != nil {
+			logs.ErrorWithFields("Remove failed container failed", map[string]interface{}{"id": failedID, "error": err})
+		}
+	}
+	if name != "" {
+		if err := cli.ContainerRename(ctx, containerID, name); err != nil {
+			logs.ErrorWithFields("Rollback rename failed", map[string]interface{}{"id": containerID, "name": name, "error": err})
+		}
+	}
+	if start {
+		if err := cli.ContainerStart(ctx, containerID, container.StartOptions{}); err != nil {
+			logs.ErrorWithFields("Rollback start failed", map[string]interface{}{"id": containerID, "error": err})
+		}
+	}
+}
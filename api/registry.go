@@ -0,0 +1,465 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// registryTimeout 单次仓库请求的超时时间
+	registryTimeout = 30 * time.Second
+	// registryMaxPages 标签分页的最大页数，防止异常仓库无限翻页
+	registryMaxPages = 50
+	// remoteManifestLimit 一次最多查询的清单数量
+	remoteManifestLimit = 20
+	// registryClientID 用身份令牌换取访问令牌时上报的 client_id
+	registryClientID = "docker-mcp"
+)
+
+// manifestAccept 同时接受多平台索引和单平台清单
+var manifestAccept = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// RemoteTagsOptions 查询远端仓库标签的参数，Filter 为标签的通配符，如 16*
+type RemoteTagsOptions struct {
+	Image     string `json:"image"`
+	Filter    string `json:"filter,omitempty"`
+	Sort      string `json:"sort,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+	Manifests bool   `json:"manifests,omitempty"`
+}
+
+// ListRemoteTags 通过仓库 v2 接口列出远端标签，镜像带标签时同时返回该标签当前的清单
+func ListRemoteTags(ctx context.Context, opts RemoteTagsOptions) (*resp.RemoteTags, error) {
+	named, err := reference.ParseNormalizedNamed(opts.Image)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image reference %q: %v", opts.Image, err)
+	}
+	if opts.Filter != "" {
+		if _, err := path.Match(opts.Filter, ""); err != nil {
+			return nil, fmt.Errorf("invalid filter %q: %v", opts.Filter, err)
+		}
+	}
+	rc := newRegistryClient(reference.Domain(named), reference.Path(named))
+	tags, truncated, err := rc.tags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if truncated {
+		logs.WarnWithFields("Remote tag list truncated", map[string]interface{}{"image": opts.Image, "pages": registryMaxPages, "tags": len(tags)})
+	}
+
+	result := &resp.RemoteTags{Registry: rc.host, Repository: reference.FamiliarName(named), Tags: make([]string, 0), Truncated: truncated}
+	for _, tag := range tags {
+		if opts.Filter != "" {
+			if ok, _ := path.Match(opts.Filter, tag); !ok {
+				continue
+			}
+		}
+		result.Tags = append(result.Tags, tag)
+	}
+	result.Total = len(result.Tags)
+	if err := sortTags(result.Tags, opts.Sort); err != nil {
+		return nil, err
+	}
+	if opts.Limit > 0 && len(result.Tags) > opts.Limit {
+		result.Tags = result.Tags[:opts.Limit]
+	}
+
+	if tagged, ok := named.(reference.Tagged); ok {
+		current := rc.manifest(ctx, tagged.Tag())
+		result.Current = &current
+	}
+	if opts.Manifests {
+		if len(result.Tags) > remoteManifestLimit {
+			return nil, fmt.Errorf("manifests can be fetched for at most %d tags, got %d; narrow filter or set limit", remoteManifestLimit, len(result.Tags))
+		}
+		for _, tag := range result.Tags {
+			result.Manifests = append(result.Manifests, rc.manifest(ctx, tag))
+		}
+	}
+	logs.InfoWithFields("List remote tags success", map[string]interface{}{"image": opts.Image, "total": result.Total})
+	return result, nil
+}
+
+// sortTags 默认按版本号倒序，数字部分按数值比较，16.10 排在 16.9 之前
+func sortTags(tags []string, by string) error {
+	switch by {
+	case "", "version":
+		sort.SliceStable(tags, func(i, j int) bool { return compareVersion(tags[i], tags[j]) > 0 })
+	case "name":
+		sort.Strings(tags)
+	default:
+		return fmt.Errorf("unsupported sort %q, expected version or name", by)
+	}
+	return nil
+}
+
+// compareVersion 把标签拆成数字段和非数字段逐段比较
+func compareVersion(a, b string) int {
+	as, bs := splitVersion(a), splitVersion(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, xErr := strconv.ParseUint(as[i], 10, 64)
+		y, yErr := strconv.ParseUint(bs[i], 10, 64)
+		switch {
+		case xErr == nil && yErr == nil:
+			if x != y {
+				if x < y {
+					return -1
+				}
+				return 1
+			}
+		// 数字段排在文字段之前，版本号标签排在 latest 等标签之前
+		case xErr == nil:
+			return 1
+		case yErr == nil:
+			return -1
+		case as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	// 一个是另一个的前缀时，-alpine 等后缀版本排在正式版本之后，16.10 排在 16 之前
+	switch {
+	case len(as) > len(bs):
+		if strings.HasPrefix(as[len(bs)], "-") {
+			return -1
+		}
+		return 1
+	case len(as) < len(bs):
+		if strings.HasPrefix(bs[len(as)], "-") {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
+
+func splitVersion(tag string) []string {
+	var parts []string
+	start := 0
+	for i := 1; i <= len(tag); i++ {
+		if i == len(tag) || isDigit(tag[i]) != isDigit(tag[i-1]) {
+			parts = append(parts, tag[start:i])
+			start = i
+		}
+	}
+	return parts
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// registryClient 访问单个仓库的 v2 接口，按 WWW-Authenticate 质询获取令牌
+type registryClient struct {
+	host     string
+	endpoint string
+	repo     string
+	// local 仓库在本机，允许通过 http 发送凭据
+	local  bool
+	client *http.Client
+	token  string
+}
+
+func newRegistryClient(domain, repo string) *registryClient {
+	host := RegistryHost(domain)
+	endpoint := "https://" + domain
+	local := host != DockerHubHost && isLoopbackHost(domain)
+	if host == DockerHubHost {
+		endpoint = "https://registry-1.docker.io"
+	} else if local {
+		// 与 docker 一致，本机仓库允许使用 http
+		endpoint = "http://" + domain
+	}
+	return &registryClient{
+		host:     host,
+		endpoint: endpoint,
+		repo:     repo,
+		local:    local,
+		client:   &http.Client{Timeout: registryTimeout},
+	}
+}
+
+// tags 跟随 Link 头分页读取全部标签，超过 registryMaxPages 页时停止并返回 truncated
+func (c *registryClient) tags(ctx context.Context) (tags []string, truncated bool, err error) {
+	next := c.endpoint + "/v2/" + c.repo + "/tags/list?n=1000"
+	for page := 0; next != ""; page++ {
+		if page == registryMaxPages {
+			return tags, true, nil
+		}
+		res, err := c.get(ctx, next, nil)
+		if err != nil {
+			return nil, false, err
+		}
+		var body struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		res.Body.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("decode tag list failed: %v", err)
+		}
+		tags = append(tags, body.Tags...)
+		next = c.nextLink(res)
+	}
+	return tags, false, nil
+}
+
+// nextLink 解析 Link: </v2/app/tags/list?last=x&n=1000>; rel="next"
+func (c *registryClient) nextLink(res *http.Response) string {
+	link := res.Header.Get("Link")
+	if link == "" || !strings.Contains(link, `rel="next"`) {
+		return ""
+	}
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	target, err := res.Request.URL.Parse(link[start+1 : end])
+	if err != nil {
+		return ""
+	}
+	return target.String()
+}
+
+// manifest 查询单个标签的清单，失败时记录在 Error 中而不是中断整个查询
+func (c *registryClient) manifest(ctx context.Context, tag string) resp.RemoteManifest {
+	result := resp.RemoteManifest{Tag: tag}
+	res, err := c.get(ctx, c.endpoint+"/v2/"+c.repo+"/manifests/"+tag, map[string]string{"Accept": strings.Join(manifestAccept, ", ")})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Digest = res.Header.Get("Docker-Content-Digest")
+	if result.Digest == "" {
+		result.Digest = fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	}
+	var manifest struct {
+		MediaType string `json:"mediaType"`
+		Config    struct {
+			Size int64 `json:"size"`
+		} `json:"config"`
+		Layers []struct {
+			Size int64 `json:"size"`
+		} `json:"layers"`
+		Manifests []struct {
+			Platform *struct {
+				OS           string `json:"os"`
+				Architecture string `json:"architecture"`
+				Variant      string `json:"variant"`
+			} `json:"platform"`
+		} `json:"manifests"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		result.Error = fmt.Sprintf("decode manifest failed: %v", err)
+		return result
+	}
+	result.MediaType = manifest.MediaType
+	if result.MediaType == "" {
+		result.MediaType = res.Header.Get("Content-Type")
+	}
+	// 单平台清单的大小为配置和各层压缩后大小之和
+	if len(manifest.Layers) > 0 {
+		result.Size = manifest.Config.Size
+		for _, layer := range manifest.Layers {
+			result.Size += layer.Size
+		}
+	}
+	for _, m := range manifest.Manifests {
+		// 跳过 unknown/unknown 的构建证明清单
+		if m.Platform == nil || m.Platform.OS == "unknown" {
+			continue
+		}
+		platform := m.Platform.OS + "/" + m.Platform.Architecture
+		if m.Platform.Variant != "" {
+			platform += "/" + m.Platform.Variant
+		}
+		result.Platforms = append(result.Platforms, platform)
+	}
+	return result
+}
+
+// get 发送请求，遇到 401 时按质询获取令牌或使用基本认证后重试一次
+func (c *registryClient) get(ctx context.Context, target string, headers map[string]string) (*http.Response, error) {
+	res, err := c.do(ctx, target, headers)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		challenge := res.Header.Get("WWW-Authenticate")
+		res.Body.Close()
+		if err := c.authorize(ctx, challenge); err != nil {
+			return nil, err
+		}
+		if res, err = c.do(ctx, target, headers); err != nil {
+			return nil, err
+		}
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, fmt.Errorf("registry %s returned %s: %s", c.host, res.Status, strings.TrimSpace(string(body)))
+	}
+	return res, nil
+}
+
+func (c *registryClient) do(ctx context.Context, target string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+	return c.client.Do(req)
+}
+
+// authorize 处理 Basic 和 Bearer 两种质询，凭据来自凭据存储
+func (c *registryClient) authorize(ctx context.Context, challenge string) error {
+	auth, hasAuth := Credentials.Get(c.host)
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if !hasAuth || auth.Username == "" {
+			return fmt.Errorf("registry %s requires authentication, log in with mcp_docker_auth_registry first", c.host)
+		}
+		// 身份令牌只能在 Bearer 令牌服务换取访问令牌，不能当作密码使用
+		if auth.Password == "" && auth.IdentityToken != "" {
+			return fmt.Errorf("registry %s requires basic authentication but only an identity token is stored for it, log in with a password", c.host)
+		}
+		c.token = "Basic " + base64.StdEncoding.EncodeToString([]byte(auth.Username+":"+auth.Password))
+		return nil
+	case "bearer":
+		if hasAuth && auth.RegistryToken != "" {
+			c.token = "Bearer " + auth.RegistryToken
+			return nil
+		}
+		token, err := c.fetchToken(ctx, params, auth, hasAuth)
+		if err != nil {
+			return err
+		}
+		c.token = "Bearer " + token
+		return nil
+	}
+	return fmt.Errorf("registry %s returned unsupported authentication challenge %q", c.host, challenge)
+}
+
+// fetchToken 向质询中的 realm 申请仓库的 pull 令牌。有身份令牌时按 OAuth2 refresh_token 方式 POST，
+// 否则用 GET 加基本认证（匿名时不带认证）
+func (c *registryClient) fetchToken(ctx context.Context, params map[string]string, auth registry.AuthConfig, hasAuth bool) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme == "" {
+		return "", fmt.Errorf("registry %s returned invalid token realm %q", c.host, params["realm"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + c.repo + ":pull"
+	}
+	withCredentials := hasAuth && (auth.Username != "" || auth.IdentityToken != "")
+	// 质询中的 realm 由仓库决定，不能把密码或令牌以明文发给任意地址
+	if withCredentials && realm.Scheme != "https" && !c.local {
+		return "", fmt.Errorf("registry %s requested credentials for non-https token realm %s, refusing to send them", c.host, realm.Redacted())
+	}
+
+	var req *http.Request
+	if hasAuth && auth.IdentityToken != "" {
+		form := url.Values{}
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", auth.IdentityToken)
+		form.Set("client_id", registryClientID)
+		form.Set("scope", scope)
+		if params["service"] != "" {
+			form.Set("service", params["service"])
+		}
+		if req, err = http.NewRequestWithContext(ctx, http.MethodPost, realm.String(), strings.NewReader(form.Encode())); err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		query := realm.Query()
+		if params["service"] != "" {
+			query.Set("service", params["service"])
+		}
+		query.Set("scope", scope)
+		realm.RawQuery = query.Encode()
+		if req, err = http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil); err != nil {
+			return "", err
+		}
+		if withCredentials {
+			req.SetBasicAuth(auth.Username, auth.Password)
+		}
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("get token from %s failed: %s", realm.Host, res.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode token response failed: %v", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("token response from %s contains no token", realm.Host)
+}
+
+// parseChallenge 解析 Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := make(map[string]string)
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimSpace(rest), "=")
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		rest = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return strings.ToLower(scheme), params
+}
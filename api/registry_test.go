@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/docker/docker/api/types/registry"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestRegistry 启动本机仓库，返回镜像引用使用的主机名；httptest 监听回环地址，客户端会使用 http
+func newTestRegistry(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	host := strings.TrimPrefix(server.URL, "http://")
+	t.Cleanup(func() {
+		Credentials.mu.Lock()
+		delete(Credentials.auths, RegistryHost(host))
+		Credentials.mu.Unlock()
+	})
+	return host
+}
+
+func writeTags(w http.ResponseWriter, tags ...string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": "team/app", "tags": tags})
+}
+
+func TestListRemoteTagsBearerPagination(t *testing.T) {
+	var tokenRequests int
+	mux := http.NewServeMux()
+	var host string
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "alice" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if got := r.URL.Query().Get("scope"); got != "repository:team/app:pull" {
+			t.Errorf("scope = %q", got)
+		}
+		if got := r.URL.Query().Get("service"); got != "test-registry" {
+			t.Errorf("service = %q", got)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "tok"})
+	})
+	mux.HandleFunc("/v2/team/app/tags/list", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="test-registry",scope="repository:team/app:pull"`, host))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</v2/team/app/tags/list?last=16&n=1000>; rel="next"`)
+			writeTags(w, "16", "16.9", "latest")
+			return
+		}
+		writeTags(w, "16.10", "16-alpine")
+	})
+	host = newTestRegistry(t, mux)
+	Credentials.Set(registry.AuthConfig{ServerAddress: host, Username: "alice", Password: "secret"})
+
+	result, err := ListRemoteTags(context.Background(), RemoteTagsOptions{Image: host + "/team/app"})
+	if err != nil {
+		t.Fatalf("ListRemoteTags: %v", err)
+	}
+	want := []string{"16.10", "16.9", "16", "16-alpine", "latest"}
+	if !reflect.DeepEqual(result.Tags, want) {
+		t.Errorf("tags = %v, want %v", result.Tags, want)
+	}
+	if result.Total != len(want) || result.Truncated {
+		t.Errorf("total = %d, truncated = %v", result.Total, result.Truncated)
+	}
+	if tokenRequests != 1 {
+		t.Errorf("token requested %d times, want 1", tokenRequests)
+	}
+}
+
+func TestListRemoteTagsIdentityToken(t *testing.T) {
+	mux := http.NewServeMux()
+	var host string
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("token method = %s, want POST", r.Method)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("refresh_token") != "refresh-me" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if got := r.PostForm.Get("scope"); got != "repository:team/app:pull" {
+			t.Errorf("scope = %q", got)
+		}
+		if got := r.PostForm.Get("service"); got != "test-registry" {
+			t.Errorf("service = %q", got)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "oauth-tok"})
+	})
+	mux.HandleFunc("/v2/team/app/tags/list", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer oauth-tok" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="test-registry"`, host))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeTags(w, "1", "2")
+	})
+	host = newTestRegistry(t, mux)
+	// 登录返回身份令牌后只保存令牌，不保存密码
+	Credentials.Set(registry.AuthConfig{ServerAddress: host, Username: "alice", IdentityToken: "refresh-me"})
+
+	result, err := ListRemoteTags(context.Background(), RemoteTagsOptions{Image: host + "/team/app"})
+	if err != nil {
+		t.Fatalf("ListRemoteTags: %v", err)
+	}
+	if want := []string{"2", "1"}; !reflect.DeepEqual(result.Tags, want) {
+		t.Errorf("tags = %v, want %v", result.Tags, want)
+	}
+}
+
+func TestListRemoteTagsBasic(t *testing.T) {
+	host := newTestRegistry(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "bob" || pass != "hunter2" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeTags(w, "1.0", "1.2", "1.10")
+	}))
+
+	if _, err := ListRemoteTags(context.Background(), RemoteTagsOptions{Image: host + "/team/app"}); err == nil {
+		t.Fatal("expected an error without credentials")
+	}
+	Credentials.Set(registry.AuthConfig{ServerAddress: host, Username: "bob", Password: "hunter2"})
+	result, err := ListRemoteTags(context.Background(), RemoteTagsOptions{Image: host + "/team/app", Filter: "1.1*"})
+	if err != nil {
+		t.Fatalf("ListRemoteTags: %v", err)
+	}
+	if want := []string{"1.10"}; !reflect.DeepEqual(result.Tags, want) {
+		t.Errorf("tags = %v, want %v", result.Tags, want)
+	}
+}
+
+func TestListRemoteTagsTruncated(t *testing.T) {
+	pages := 0
+	host := newTestRegistry(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		w.Header().Set("Link", fmt.Sprintf(`</v2/team/app/tags/list?last=%d&n=1000>; rel="next"`, pages))
+		writeTags(w, fmt.Sprintf("v%d", pages))
+	}))
+
+	result, err := ListRemoteTags(context.Background(), RemoteTagsOptions{Image: host + "/team/app"})
+	if err != nil {
+		t.Fatalf("ListRemoteTags: %v", err)
+	}
+	if !result.Truncated {
+		t.Error("expected truncated result")
+	}
+	if pages != registryMaxPages || result.Total != registryMaxPages {
+		t.Errorf("pages = %d, total = %d, want %d", pages, result.Total, registryMaxPages)
+	}
+}
+
+func TestFetchTokenRefusesInsecureRealm(t *testing.T) {
+	realm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("credentials were sent to a non-https realm")
+	}))
+	defer realm.Close()
+	c := &registryClient{host: "registry.example.com", repo: "team/app", client: realm.Client()}
+	auth := registry.AuthConfig{Username: "alice", Password: "secret"}
+
+	if _, err := c.fetchToken(context.Background(), map[string]string{"realm": realm.URL + "/token"}, auth, true); err == nil {
+		t.Fatal("expected fetchToken to refuse a non-https realm")
+	}
+}
+
+func TestCompareVersion(t *testing.T) {
+	tags := []string{"latest", "16", "16-alpine", "16.9", "16.10-alpine", "16.10", "15.4"}
+	if err := sortTags(tags, "version"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"16.10", "16.10-alpine", "16.9", "16", "16-alpine", "15.4", "latest"}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("sorted = %v, want %v", tags, want)
+	}
+
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"16.10", "16.9", 1},
+		{"16.9", "16", 1},
+		{"16", "16-alpine", 1},
+		{"16-alpine", "16", -1},
+		{"1.2.3", "1.2.3", 0},
+		{"2", "latest", 1},
+	}
+	for _, c := range cases {
+		if got := compareVersion(c.a, c.b); got != c.want {
+			t.Errorf("compareVersion(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+	"regexp"
+	"strings"
+)
+
+// 删除运行中容器的处理方式
+const (
+	RemoveRefuse = "refuse"
+	RemoveStop   = "stop"
+	RemoveForce  = "force"
+)
+
+// anonymousVolumeName 匿名卷的名称是64位十六进制
+var anonymousVolumeName = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type RemoveOptions struct {
+	// Mode refuse 拒绝删除运行中的容器，stop 先优雅停止再删除，force 直接强制删除
+	Mode string
+	// StopTimeout stop 模式下等待的秒数，为空时使用容器自身的配置
+	StopTimeout *int
+	// RemoveVolumes 同时删除匿名卷，命名卷不受影响
+	RemoveVolumes bool
+}
+
+// RemoveContainers 删除指定ID和匹配标签的容器，单个失败不影响其余容器，结果逐个返回
+func RemoveContainers(ctx context.Context, cli *client.Client, ids []string, labels []string, opts RemoveOptions) ([]resp.ContainerRemove, error) {
+	if opts.Mode == "" {
+		opts.Mode = RemoveStop
+	}
+	if opts.Mode != RemoveRefuse && opts.Mode != RemoveStop && opts.Mode != RemoveForce {
+		return nil, fmt.Errorf("unsupported remove mode %q, expected refuse, stop or force", opts.Mode)
+	}
+	// 先把ID和名称解析为完整ID再去重，同一个容器可能同时被ID、名称和标签选中
+	results := make([]resp.ContainerRemove, 0)
+	targets := make([]string, 0, len(ids))
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		inspect, err := cli.ContainerInspect(ctx, id)
+		if err != nil {
+			results = append(results, resp.ContainerRemove{ID: id, Error: err.Error()})
+			continue
+		}
+		if !seen[inspect.ID] {
+			seen[inspect.ID] = true
+			targets = append(targets, inspect.ID)
+		}
+	}
+	if len(labels) > 0 {
+		args := filters.NewArgs()
+		for _, label := range labels {
+			args.Add("label", label)
+		}
+		list, err := cli.ContainerList(ctx, container.ListOptions{All: true, Filters: args})
+		if err != nil {
+			logs.ErrorWithFields("ContainerList failed", map[string]interface{}{"labels": labels, "error": err})
+			return nil, err
+		}
+		for _, ctr := range list {
+			if !seen[ctr.ID] {
+				seen[ctr.ID] = true
+				targets = append(targets, ctr.ID)
+			}
+		}
+	}
+	if len(targets) == 0 && len(results) == 0 {
+		return nil, fmt.Errorf("no containers matched")
+	}
+
+	for _, target := range targets {
+		results = append(results, removeContainer(ctx, cli, target, opts))
+	}
+	return results, nil
+}
+
+func removeContainer(ctx context.Context, cli *client.Client, target string, opts RemoveOptions) resp.ContainerRemove {
+	result := resp.ContainerRemove{ID: target}
+	inspect, err := cli.ContainerInspect(ctx, target)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.ID = inspect.ID
+	result.Name = strings.TrimPrefix(inspect.Name, "/")
+	for _, m := range inspect.Mounts {
+		if m.Type == mount.TypeVolume && anonymousVolumeName.MatchString(m.Name) {
+			result.AnonymousVolumes = append(result.AnonymousVolumes, m.Name)
+		}
+	}
+
+	running := inspect.State != nil && (inspect.State.Running || inspect.State.Restarting)
+	if running {
+		switch opts.Mode {
+		case RemoveRefuse:
+			result.Error = "container is running, stop it first or use mode stop or force"
+			return result
+		case RemoveStop:
+			if err := cli.ContainerStop(ctx, inspect.ID, container.StopOptions{Timeout: opts.StopTimeout}); err != nil {
+				logs.ErrorWithFields("ContainerStop failed", map[string]interface{}{"id": inspect.ID, "error": err})
+				result.Error = err.Error()
+				return result
+			}
+			result.Stopped = true
+		}
+	}
+	if err := cli.ContainerRemove(ctx, inspect.ID, container.RemoveOptions{
+		Force:         opts.Mode == RemoveForce,
+		RemoveVolumes: opts.RemoveVolumes,
+	}); err != nil {
+		logs.ErrorWithFields("ContainerRemove failed", map[string]interface{}{"id": inspect.ID, "error": err})
+		result.Error = err.Error()
+		return result
+	}
+	result.Removed = true
+	result.VolumesRemoved = opts.RemoveVolumes && len(result.AnonymousVolumes) > 0
+	logs.InfoWithFields("ContainerRemove success", map[string]interface{}{"id": inspect.ID, "name": result.Name, "mode": opts.Mode})
+	return result
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"encoding/json"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Replica 直接从 inspect 结果复制出的创建参数。与 ContainerSpec 不同，它保留 LogConfig、Devices、DNS、
+// SecurityOpt、Ulimits、ShmSize、Sysctls、Init、挂载选项、GPU 等全部配置，用于重建和克隆容器
+type Replica struct {
+	Name       string
+	Config     *container.Config
+	HostConfig *container.HostConfig
+	// Networks 第一个网络在创建时指定，其余在创建后连接
+	Networks []ReplicaNetwork
+}
+
+type ReplicaNetwork struct {
+	Name     string
+	Endpoint *network.EndpointSettings
+}
+
+// InspectReplica 读取容器和镜像配置，复制出重建该容器所需的全部参数
+func InspectReplica(ctx context.Context, cli *client.Client, containerID string) (*Replica, container.InspectResponse, error) {
+	inspect, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		logs.ErrorWithFields("ContainerInspect failed", map[string]interface{}{"id": containerID, "error": err})
+		return nil, inspect, err
+	}
+	if inspect.Config == nil || inspect.HostConfig == nil {
+		return nil, inspect, fmt.Errorf("container %s has no configuration", containerID)
+	}
+	var imageConfig *container.Config
+	imageInspect, err := cli.ImageInspect(ctx, inspect.Image)
+	if err == nil {
+		imageConfig = imageInspect.Config
+	} else if !client.IsErrNotFound(err) {
+		return nil, inspect, err
+	} else {
+		logs.WarnWithFields("Image of container not found, keeping image defaults", map[string]interface{}{"id": containerID, "image": inspect.Image})
+	}
+	replica, err := ReplicaFromInspect(inspect, imageConfig)
+	return replica, inspect, err
+}
+
+// ReplicaFromInspect 深拷贝 Config 和 HostConfig，去掉从旧镜像继承的默认值，让新镜像的默认值生效
+func ReplicaFromInspect(inspect container.InspectResponse, imageConfig *container.Config) (*Replica, error) {
+	replica := &Replica{
+		Name:       strings.TrimPrefix(inspect.Name, "/"),
+		Config:     &container.Config{},
+		HostConfig: &container.HostConfig{},
+	}
+	if err := deepCopy(inspect.Config, replica.Config); err != nil {
+		return nil, err
+	}
+	if err := deepCopy(inspect.HostConfig, replica.HostConfig); err != nil {
+		return nil, err
+	}
+	config := replica.Config
+	// 默认主机名是容器ID的前12位，保留会让新容器沿用旧ID
+	if config.Hostname != "" && strings.HasPrefix(inspect.ID, config.Hostname) {
+		config.Hostname = ""
+	}
+	if imageConfig != nil {
+		stripImageDefaults(config, imageConfig)
+	}
+	replica.keepNamedVolumes(inspect.Mounts)
+	replica.Networks = replicaNetworks(inspect)
+	return replica, nil
+}
+
+// stripImageDefaults 去掉和镜像相同的配置，否则旧镜像的 ENV（如 NGINX_VERSION）、CMD、LABEL 会覆盖新镜像
+func stripImageDefaults(config, imageConfig *container.Config) {
+	imageEnv := make(map[string]bool, len(imageConfig.Env))
+	for _, e := range imageConfig.Env {
+		imageEnv[e] = true
+	}
+	env := make([]string, 0, len(config.Env))
+	for _, e := range config.Env {
+		if !imageEnv[e] {
+			env = append(env, e)
+		}
+	}
+	config.Env = env
+	for k, v := range config.Labels {
+		if imageValue, ok := imageConfig.Labels[k]; ok && imageValue == v {
+			delete(config.Labels, k)
+		}
+	}
+	// 覆盖了 ENTRYPOINT 时 Docker 会清空镜像的 CMD，此时容器中的 CMD 一定是显式指定的
+	if reflect.DeepEqual([]string(config.Entrypoint), []string(imageConfig.Entrypoint)) {
+		config.Entrypoint = nil
+		if reflect.DeepEqual([]string(config.Cmd), []string(imageConfig.Cmd)) {
+			config.Cmd = nil
+		}
+	}
+	if config.WorkingDir == imageConfig.WorkingDir {
+		config.WorkingDir = ""
+	}
+	if config.User == imageConfig.User {
+		config.User = ""
+	}
+	if config.StopSignal == imageConfig.StopSignal {
+		config.StopSignal = ""
+	}
+	if reflect.DeepEqual(config.Shell, imageConfig.Shell) {
+		config.Shell = nil
+	}
+	if reflect.DeepEqual(config.Healthcheck, imageConfig.Healthcheck) {
+		config.Healthcheck = nil
+	}
+	for port := range imageConfig.ExposedPorts {
+		delete(config.ExposedPorts, port)
+	}
+	for dest := range imageConfig.Volumes {
+		delete(config.Volumes, dest)
+	}
+}
+
+// keepNamedVolumes 匿名卷和镜像声明的卷按卷名挂到新容器，保证数据不丢失
+func (r *Replica) keepNamedVolumes(mounts []container.MountPoint) {
+	covered := make(map[string]bool)
+	for _, b := range r.HostConfig.Binds {
+		if parts := strings.Split(b, ":"); len(parts) >= 2 {
+			covered[parts[1]] = true
+		}
+	}
+	for _, m := range mounts {
+		if m.Type != mount.TypeVolume || m.Name == "" || covered[m.Destination] {
+			continue
+		}
+		found := false
+		for i := range r.HostConfig.Mounts {
+			if r.HostConfig.Mounts[i].Target == m.Destination {
+				found = true
+				if r.HostConfig.Mounts[i].Type == mount.TypeVolume && r.HostConfig.Mounts[i].Source == "" {
+					r.HostConfig.Mounts[i].Source = m.Name
+				}
+			}
+		}
+		if found {
+			continue
+		}
+		bind := m.Name + ":" + m.Destination
+		if !m.RW {
+			bind += ":ro"
+		}
+		r.HostConfig.Binds = append(r.HostConfig.Binds, bind)
+	}
+}
+
+// ReplaceVolume 把挂载到 destination 的卷替换为 volumeName，用于克隆时复制卷
+func (r *Replica) ReplaceVolume(destination, volumeName string) {
+	for i, b := range r.HostConfig.Binds {
+		parts := strings.Split(b, ":")
+		if len(parts) >= 2 && parts[1] == destination {
+			parts[0] = volumeName
+			r.HostConfig.Binds[i] = strings.Join(parts, ":")
+		}
+	}
+	for i := range r.HostConfig.Mounts {
+		if r.HostConfig.Mounts[i].Target == destination && r.HostConfig.Mounts[i].Type == mount.TypeVolume {
+			r.HostConfig.Mounts[i].Source = volumeName
+		}
+	}
+}
+
+// replicaNetworks 复制每个网络的用户配置：静态IP、别名、链接和驱动参数，运行时分配的地址不复制
+func replicaNetworks(inspect container.InspectResponse) []ReplicaNetwork {
+	mode := inspect.HostConfig.NetworkMode
+	if inspect.NetworkSettings == nil || mode.IsHost() || mode.IsNone() || mode.IsContainer() {
+		return nil
+	}
+	primary := string(mode)
+	if mode.IsDefault() {
+		primary = network.NetworkBridge
+	}
+	shortID := inspect.ID
+	if len(shortID) > 12 {
+		shortID = shortID[:12]
+	}
+	networks := make([]ReplicaNetwork, 0, len(inspect.NetworkSettings.Networks))
+	for name, settings := range inspect.NetworkSettings.Networks {
+		if settings == nil {
+			continue
+		}
+		endpoint := &network.EndpointSettings{
+			Links:      settings.Links,
+			DriverOpts: settings.DriverOpts,
+			GwPriority: settings.GwPriority,
+		}
+		if settings.IPAMConfig != nil {
+			ipam := *settings.IPAMConfig
+			endpoint.IPAMConfig = &ipam
+		}
+		// 旧版本 API 会把短ID自动加入别名
+		for _, alias := range settings.Aliases {
+			if alias != shortID {
+				endpoint.Aliases = append(endpoint.Aliases, alias)
+			}
+		}
+		networks = append(networks, ReplicaNetwork{Name: name, Endpoint: endpoint})
+	}
+	sort.SliceStable(networks, func(i, j int) bool {
+		if (networks[i].Name == primary) != (networks[j].Name == primary) {
+			return networks[i].Name == primary
+		}
+		return networks[i].Name < networks[j].Name
+	})
+	return networks
+}
+
+// CreateReplica 按复制的参数创建容器，连接其余网络失败时删除半成品容器
+func CreateReplica(ctx context.Context, cli *client.Client, replica *Replica) (container.CreateResponse, error) {
+	networking := &network.NetworkingConfig{}
+	if len(replica.Networks) > 0 {
+		first := replica.Networks[0]
+		networking.EndpointsConfig = map[string]*network.EndpointSettings{first.Name: first.Endpoint}
+	}
+	create, err := cli.ContainerCreate(ctx, replica.Config, replica.HostConfig, networking, nil, replica.Name)
+	if err != nil {
+		logs.ErrorWithFields("ContainerCreate failed", map[string]interface{}{"image": replica.Config.Image, "name": replica.Name, "error": err})
+		return create, err
+	}
+	for i := 1; i < len(replica.Networks); i++ {
+		n := replica.Networks[i]
+		if err := cli.NetworkConnect(ctx, n.Name, create.ID, n.Endpoint); err != nil {
+			logs.ErrorWithFields("NetworkConnect failed", map[string]interface{}{"id": create.ID, "network": n.Name, "error": err})
+			_ = cli.ContainerRemove(ctx, create.ID, container.RemoveOptions{Force: true})
+			return create, err
+		}
+	}
+	logs.InfoWithFields("CreateReplica success", map[string]interface{}{"id": create.ID, "image": replica.Config.Image, "name": replica.Name})
+	return create, nil
+}
+
+func deepCopy(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
@@ -0,0 +1,515 @@
+package api
+
+import (
+	"fmt"
+	"github.com/docker/go-connections/nat"
+	"strconv"
+	"strings"
+)
+
+// RunCommand 解析 docker run 命令行得到的结果
+type RunCommand struct {
+	Spec ContainerSpec `json:"spec"`
+	// Pull 对应 --pull，默认 missing
+	Pull string `json:"pull"`
+	// Ignored 被接受但没有效果的参数，例如 -d（工具创建的容器总是后台运行）
+	Ignored []string `json:"ignored,omitempty"`
+}
+
+type runFlag struct {
+	names    []string
+	hasValue bool
+	apply    func(p *runParser, value string) error
+}
+
+type runParser struct {
+	cmd         RunCommand
+	aliases     []string
+	ip          string
+	healthcheck HealthcheckSpec
+	hasHealth   bool
+}
+
+var runFlags = []runFlag{
+	{[]string{"-e", "--env"}, true, func(p *runParser, v string) error {
+		if !strings.Contains(v, "=") {
+			return fmt.Errorf("env %q has no value; use KEY=value", v)
+		}
+		p.cmd.Spec.Env = append(p.cmd.Spec.Env, v)
+		return nil
+	}},
+	{[]string{"-p", "--publish"}, true, func(p *runParser, v string) error {
+		mappings, err := nat.ParsePortSpec(v)
+		if err != nil {
+			return fmt.Errorf("invalid port mapping %q: %v", v, err)
+		}
+		for _, m := range mappings {
+			hostPort := m.Binding.HostPort
+			if hostPort == "" {
+				hostPort = "0"
+			}
+			p.cmd.Spec.Ports = append(p.cmd.Spec.Ports, PortSpec{
+				HostIP:        m.Binding.HostIP,
+				HostPort:      hostPort,
+				ContainerPort: m.Port.Port(),
+				Protocol:      m.Port.Proto(),
+			})
+		}
+		return nil
+	}},
+	{[]string{"--expose"}, true, func(p *runParser, v string) error {
+		mappings, err := nat.ParsePortSpec(v)
+		if err != nil {
+			return fmt.Errorf("invalid exposed port %q: %v", v, err)
+		}
+		for _, m := range mappings {
+			p.cmd.Spec.Ports = append(p.cmd.Spec.Ports, PortSpec{
+				ContainerPort: m.Port.Port(),
+				Protocol:      m.Port.Proto(),
+			})
+		}
+		return nil
+	}},
+	{[]string{"-v", "--volume"}, true, func(p *runParser, v string) error {
+		p.cmd.Spec.Volumes = append(p.cmd.Spec.Volumes, v)
+		return nil
+	}},
+	{[]string{"--mount"}, true, func(p *runParser, v string) error {
+		return p.parseMount(v)
+	}},
+	{[]string{"--tmpfs"}, true, func(p *runParser, v string) error {
+		mountPath, options, _ := strings.Cut(v, ":")
+		if p.cmd.Spec.Tmpfs == nil {
+			p.cmd.Spec.Tmpfs = map[string]string{}
+		}
+		p.cmd.Spec.Tmpfs[mountPath] = options
+		return nil
+	}},
+	{[]string{"--name"}, true, func(p *runParser, v string) error {
+		p.cmd.Spec.Name = v
+		return nil
+	}},
+	{[]string{"-w", "--workdir"}, true, func(p *runParser, v string) error {
+		p.cmd.Spec.WorkingDir = v
+		return nil
+	}},
+	{[]string{"-u", "--user"}, true, func(p *runParser, v string) error {
+		p.cmd.Spec.User = v
+		return nil
+	}},
+	{[]string{"-h", "--hostname"}, true, func(p *runParser, v string) error {
+		p.cmd.Spec.Hostname = v
+		return nil
+	}},
+	{[]string{"-l", "--label"}, true, func(p *runParser, v string) error {
+		key, value, _ := strings.Cut(v, "=")
+		if p.cmd.Spec.Labels == nil {
+			p.cmd.Spec.Labels = map[string]string{}
+		}
+		p.cmd.Spec.Labels[key] = value
+		return nil
+	}},
+	{[]string{"--entrypoint"}, true, func(p *runParser, v string) error {
+		p.cmd.Spec.Entrypoint = []string{v}
+		return nil
+	}},
+	{[]string{"--restart"}, true, func(p *runParser, v string) error {
+		if _, err := ParseRestartPolicy(v); err != nil {
+			return err
+		}
+		p.cmd.Spec.RestartPolicy = v
+		return nil
+	}},
+	{[]string{"-m", "--memory"}, true, func(p *runParser, v string) error {
+		p.cmd.Spec.Memory = v
+		return nil
+	}},
+	{[]string{"--memory-swap"}, true, func(p *runParser, v string) error {
+		p.cmd.Spec.MemorySwap = v
+		return nil
+	}},
+	{[]string{"--cpus"}, true, func(p *runParser, v string) error {
+		cpus, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid --cpus %q", v)
+		}
+		p.cmd.Spec.CPUs = cpus
+		return nil
+	}},
+	{[]string{"-c", "--cpu-shares"}, true, func(p *runParser, v string) error {
+		shares, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid --cpu-shares %q", v)
+		}
+		p.cmd.Spec.CPUShares = shares
+		return nil
+	}},
+	{[]string{"--pids-limit"}, true, func(p *runParser, v string) error {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid --pids-limit %q", v)
+		}
+		p.cmd.Spec.PidsLimit = limit
+		return nil
+	}},
+	{[]string{"--network", "--net"}, true, func(p *runParser, v string) error {
+		// 支持 --network name=backend,alias=db,ip=10.0.0.5 的写法
+		if !strings.Contains(v, "=") {
+			p.cmd.Spec.Networks = append(p.cmd.Spec.Networks, NetworkSpec{Name: v})
+			return nil
+		}
+		n := NetworkSpec{}
+		for _, field := range strings.Split(v, ",") {
+			key, val, _ := strings.Cut(field, "=")
+			switch key {
+			case "name":
+				n.Name = val
+			case "alias":
+				n.Aliases = append(n.Aliases, val)
+			case "ip":
+				n.IPv4Address = val
+			default:
+				return fmt.Errorf("unsupported --network option %q", key)
+			}
+		}
+		if n.Name == "" {
+			return fmt.Errorf("--network %q has no name", v)
+		}
+		p.cmd.Spec.Networks = append(p.cmd.Spec.Networks, n)
+		return nil
+	}},
+	{[]string{"--network-alias", "--net-alias"}, true, func(p *runParser, v string) error {
+		p.aliases = append(p.aliases, v)
+		return nil
+	}},
+	{[]string{"--ip"}, true, func(p *runParser, v string) error {
+		p.ip = v
+		return nil
+	}},
+	{[]string{"--add-host"}, true, func(p *runParser, v string) error {
+		p.cmd.Spec.ExtraHosts = append(p.cmd.Spec.ExtraHosts, v)
+		return nil
+	}},
+	{[]string{"--cap-add"}, true, func(p *runParser, v string) error {
+		p.cmd.Spec.CapAdd = append(p.cmd.Spec.CapAdd, v)
+		return nil
+	}},
+	{[]string{"--cap-drop"}, true, func(p *runParser, v string) error {
+		p.cmd.Spec.CapDrop = append(p.cmd.Spec.CapDrop, v)
+		return nil
+	}},
+	{[]string{"--health-cmd"}, true, func(p *runParser, v string) error {
+		p.hasHealth = true
+		p.healthcheck.Test = []string{"CMD-SHELL", v}
+		return nil
+	}},
+	{[]string{"--health-interval"}, true, func(p *runParser, v string) error {
+		p.hasHealth = true
+		p.healthcheck.Interval = v
+		return nil
+	}},
+	{[]string{"--health-timeout"}, true, func(p *runParser, v string) error {
+		p.hasHealth = true
+		p.healthcheck.Timeout = v
+		return nil
+	}},
+	{[]string{"--health-start-period"}, true, func(p *runParser, v string) error {
+		p.hasHealth = true
+		p.healthcheck.StartPeriod = v
+		return nil
+	}},
+	{[]string{"--health-retries"}, true, func(p *runParser, v string) error {
+		retries, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid --health-retries %q", v)
+		}
+		p.hasHealth = true
+		p.healthcheck.Retries = retries
+		return nil
+	}},
+	{[]string{"--no-healthcheck"}, false, func(p *runParser, v string) error {
+		if v == "true" {
+			p.hasHealth = true
+			p.healthcheck.Test = []string{"NONE"}
+		}
+		return nil
+	}},
+	{[]string{"--pull"}, true, func(p *runParser, v string) error {
+		if v != PullAlways && v != PullMissing && v != PullNever {
+			return fmt.Errorf("invalid --pull %q, expected always, missing or never", v)
+		}
+		p.cmd.Pull = v
+		return nil
+	}},
+	{[]string{"--privileged"}, false, func(p *runParser, v string) error {
+		p.cmd.Spec.Privileged = v == "true"
+		return nil
+	}},
+	{[]string{"--rm"}, false, func(p *runParser, v string) error {
+		p.cmd.Spec.AutoRemove = v == "true"
+		return nil
+	}},
+	{[]string{"-t", "--tty"}, false, func(p *runParser, v string) error {
+		p.cmd.Spec.Tty = v == "true"
+		return nil
+	}},
+	{[]string{"-i", "--interactive"}, false, func(p *runParser, v string) error {
+		p.cmd.Spec.Interactive = v == "true"
+		return nil
+	}},
+	{[]string{"-d", "--detach"}, false, func(p *runParser, v string) error {
+		p.cmd.Ignored = append(p.cmd.Ignored, "--detach")
+		return nil
+	}},
+}
+
+func lookupRunFlag(name string) *runFlag {
+	for i := range runFlags {
+		for _, n := range runFlags[i].names {
+			if n == name {
+				return &runFlags[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ParseRunCommand 把 docker run 命令行解析为 ContainerSpec，支持引号、长短参数、合并的短参数和重复参数
+func ParseRunCommand(commandLine string) (*RunCommand, error) {
+	args, err := SplitCommandLine(commandLine)
+	if err != nil {
+		return nil, err
+	}
+	args = stripRunPrefix(args)
+
+	p := &runParser{cmd: RunCommand{Pull: PullMissing}}
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = args[i+1:]
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			// 第一个非参数是镜像，之后的内容都属于容器命令
+			positional = args[i:]
+			break
+		}
+		if strings.HasPrefix(arg, "--") {
+			name, value, hasValue := strings.Cut(arg, "=")
+			flag := lookupRunFlag(name)
+			if flag == nil {
+				return nil, fmt.Errorf("unsupported flag %s", name)
+			}
+			if flag.hasValue && !hasValue {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("flag %s needs an argument", name)
+				}
+				i++
+				value = args[i]
+			}
+			if !flag.hasValue {
+				if !hasValue {
+					value = "true"
+				} else if b, err := strconv.ParseBool(value); err != nil {
+					return nil, fmt.Errorf("invalid boolean value %q for %s", value, name)
+				} else {
+					value = strconv.FormatBool(b)
+				}
+			}
+			if err := flag.apply(p, value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// 短参数可以合并，例如 -dit 或 -p8080:80
+		shorts := arg[1:]
+		for j := 0; j < len(shorts); j++ {
+			name := "-" + string(shorts[j])
+			flag := lookupRunFlag(name)
+			if flag == nil {
+				return nil, fmt.Errorf("unsupported flag %s", name)
+			}
+			if !flag.hasValue {
+				if err := flag.apply(p, "true"); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			value := strings.TrimPrefix(shorts[j+1:], "=")
+			if value == "" {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("flag %s needs an argument", name)
+				}
+				i++
+				value = args[i]
+			}
+			if err := flag.apply(p, value); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if len(positional) == 0 {
+		return nil, fmt.Errorf("no image found in command line")
+	}
+	p.cmd.Spec.Image = positional[0]
+	if len(positional) > 1 {
+		p.cmd.Spec.Cmd = positional[1:]
+	}
+
+	if len(p.aliases) > 0 || p.ip != "" {
+		if len(p.cmd.Spec.Networks) == 0 {
+			return nil, fmt.Errorf("--network-alias and --ip require --network")
+		}
+		p.cmd.Spec.Networks[0].Aliases = p.aliases
+		p.cmd.Spec.Networks[0].IPv4Address = p.ip
+	}
+	if p.hasHealth {
+		healthcheck := p.healthcheck
+		p.cmd.Spec.Healthcheck = &healthcheck
+	}
+	return &p.cmd, nil
+}
+
+// parseMount 把 --mount 转换为 volumes 或 tmpfs 条目
+func (p *runParser) parseMount(value string) error {
+	fields := map[string]string{}
+	for _, field := range strings.Split(value, ",") {
+		key, val, hasVal := strings.Cut(strings.TrimSpace(field), "=")
+		if !hasVal {
+			val = "true"
+		}
+		fields[strings.ToLower(key)] = val
+	}
+	mountType := fields["type"]
+	if mountType == "" {
+		mountType = "volume"
+	}
+	source := firstNonEmpty(fields["source"], fields["src"])
+	target := firstNonEmpty(fields["target"], fields["destination"], fields["dst"])
+	if target == "" {
+		return fmt.Errorf("--mount %q has no target", value)
+	}
+	readOnly := firstNonEmpty(fields["readonly"], fields["ro"])
+	switch mountType {
+	case "bind", "volume":
+		if source == "" {
+			p.cmd.Spec.Volumes = append(p.cmd.Spec.Volumes, target)
+			return nil
+		}
+		volume := source + ":" + target
+		if readOnly == "true" || readOnly == "1" {
+			volume += ":ro"
+		}
+		p.cmd.Spec.Volumes = append(p.cmd.Spec.Volumes, volume)
+	case "tmpfs":
+		var options []string
+		if size := fields["tmpfs-size"]; size != "" {
+			options = append(options, "size="+size)
+		}
+		if mode := fields["tmpfs-mode"]; mode != "" {
+			options = append(options, "mode="+mode)
+		}
+		if p.cmd.Spec.Tmpfs == nil {
+			p.cmd.Spec.Tmpfs = map[string]string{}
+		}
+		p.cmd.Spec.Tmpfs[target] = strings.Join(options, ",")
+	default:
+		return fmt.Errorf("unsupported mount type %q", mountType)
+	}
+	return nil
+}
+
+// stripRunPrefix 去掉 sudo docker run / docker container run 前缀
+func stripRunPrefix(args []string) []string {
+	if len(args) > 0 && args[0] == "sudo" {
+		args = args[1:]
+	}
+	if len(args) > 0 && (args[0] == "docker" || strings.HasSuffix(args[0], "/docker")) {
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "container" {
+		args = args[1:]
+	}
+	if len(args) > 0 && (args[0] == "run" || args[0] == "create") {
+		args = args[1:]
+	}
+	return args
+}
+
+// SplitCommandLine 按 POSIX shell 规则拆分命令行，支持单双引号、反斜杠转义和续行，不做变量展开
+func SplitCommandLine(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(s) {
+				break
+			}
+			i++
+			// 反斜杠加换行表示续行
+			if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+				i++
+				continue
+			}
+			if s[i] == '\n' {
+				continue
+			}
+			cur.WriteByte(s[i])
+			inArg = true
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote in command line")
+			}
+			cur.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case c == '"':
+			inArg = true
+			closed := false
+			for i++; i < len(s); i++ {
+				if s[i] == '"' {
+					closed = true
+					break
+				}
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				cur.WriteByte(s[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated double quote in command line")
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
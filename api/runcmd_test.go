@@ -0,0 +1,266 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: `docker run nginx`, want: []string{"docker", "run", "nginx"}},
+		{in: "  a \t b\n c  ", want: []string{"a", "b", "c"}},
+		{in: `-e 'A=hello world'`, want: []string{"-e", "A=hello world"}},
+		{in: `-e "A=say \"hi\" \$HOME"`, want: []string{"-e", `A=say "hi" $HOME`}},
+		{in: `-e "A=back\slash"`, want: []string{"-e", `A=back\slash`}},
+		{in: `a\ b c`, want: []string{"a b", "c"}},
+		{in: `'it'"'"'s'`, want: []string{"it's"}},
+		{in: `''`, want: []string{""}},
+		{in: "docker run \\\n  -d \\\r\n  nginx", want: []string{"docker", "run", "-d", "nginx"}},
+		{in: `-e 'A=unterminated`, wantErr: true},
+		{in: `-e "A=unterminated`, wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := SplitCommandLine(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("SplitCommandLine(%q) = %q, want error", c.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SplitCommandLine(%q): %v", c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SplitCommandLine(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestParseRunCommand(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		want    ContainerSpec
+		pull    string
+		wantErr bool
+	}{
+		{
+			name: "prefix and image only",
+			in:   "sudo docker container run nginx",
+			want: ContainerSpec{Image: "nginx"},
+		},
+		{
+			name: "flag=value and repeated flags",
+			in:   "docker run --name=web -e A=1 --env=B=2 -e 'C=x y' --label a=1 -l b= nginx:1.25",
+			want: ContainerSpec{
+				Image:  "nginx:1.25",
+				Name:   "web",
+				Env:    []string{"A=1", "B=2", "C=x y"},
+				Labels: map[string]string{"a": "1", "b": ""},
+			},
+		},
+		{
+			name: "combined short flags and attached values",
+			in:   "docker run -dit -p8080:80 -p 127.0.0.1::443/tcp -p 53:53/udp -m=512m alpine sh -c 'echo hi'",
+			want: ContainerSpec{
+				Image:       "alpine",
+				Cmd:         []string{"sh", "-c", "echo hi"},
+				Interactive: true,
+				Tty:         true,
+				Memory:      "512m",
+				Ports: []PortSpec{
+					{HostPort: "8080", ContainerPort: "80", Protocol: "tcp"},
+					{HostIP: "127.0.0.1", HostPort: "0", ContainerPort: "443", Protocol: "tcp"},
+					{HostPort: "53", ContainerPort: "53", Protocol: "udp"},
+				},
+			},
+		},
+		{
+			name: "boolean flags with explicit values",
+			in:   "docker run --rm=false --privileged=true --pull never busybox",
+			want: ContainerSpec{Image: "busybox", Privileged: true},
+			pull: PullNever,
+		},
+		{
+			name: "mounts",
+			in: "docker run -v data:/data --mount type=bind,source=/etc/app,target=/etc/app,readonly " +
+				"--mount type=volume,target=/cache --mount type=tmpfs,destination=/run,tmpfs-size=64m,tmpfs-mode=1777 " +
+				"--tmpfs /tmp:size=16m redis",
+			want: ContainerSpec{
+				Image:   "redis",
+				Volumes: []string{"data:/data", "/etc/app:/etc/app:ro", "/cache"},
+				Tmpfs:   map[string]string{"/run": "size=64m,mode=1777", "/tmp": "size=16m"},
+			},
+		},
+		{
+			name: "healthcheck",
+			in:   `docker run --health-cmd "curl -f http://localhost/ || exit 1" --health-interval 10s --health-retries=3 nginx`,
+			want: ContainerSpec{
+				Image: "nginx",
+				Healthcheck: &HealthcheckSpec{
+					Test:     []string{"CMD-SHELL", "curl -f http://localhost/ || exit 1"},
+					Interval: "10s",
+					Retries:  3,
+				},
+			},
+		},
+		{
+			name: "no healthcheck",
+			in:   "docker run --no-healthcheck nginx",
+			want: ContainerSpec{Image: "nginx", Healthcheck: &HealthcheckSpec{Test: []string{"NONE"}}},
+		},
+		{
+			name: "networks",
+			in:   "docker run --network backend --network-alias db --ip 10.0.0.5 --network name=frontend,alias=api postgres",
+			want: ContainerSpec{
+				Image: "postgres",
+				Networks: []NetworkSpec{
+					{Name: "backend", Aliases: []string{"db"}, IPv4Address: "10.0.0.5"},
+					{Name: "frontend", Aliases: []string{"api"}},
+				},
+			},
+		},
+		{
+			name: "double dash ends flags",
+			in:   "docker run --entrypoint /bin/sh -- alpine -c ls",
+			want: ContainerSpec{Image: "alpine", Entrypoint: []string{"/bin/sh"}, Cmd: []string{"-c", "ls"}},
+		},
+		{name: "alias without network", in: "docker run --network-alias db postgres", wantErr: true},
+		{name: "unsupported flag", in: "docker run --gpus all nginx", wantErr: true},
+		{name: "missing value", in: "docker run nginx --name", want: ContainerSpec{Image: "nginx", Cmd: []string{"--name"}}},
+		{name: "flag needs argument", in: "docker run --name", wantErr: true},
+		{name: "env without value", in: "docker run -e A nginx", wantErr: true},
+		{name: "invalid restart", in: "docker run --restart sometimes nginx", wantErr: true},
+		{name: "no image", in: "docker run -d", wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParseRunCommand(c.in)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRunCommand(%q) = %+v, want error", c.in, got.Spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRunCommand(%q): %v", c.in, err)
+			}
+			if !reflect.DeepEqual(got.Spec, c.want) {
+				t.Errorf("spec = %+v\nwant %+v", got.Spec, c.want)
+			}
+			pull := c.pull
+			if pull == "" {
+				pull = PullMissing
+			}
+			if got.Pull != pull {
+				t.Errorf("pull = %q, want %q", got.Pull, pull)
+			}
+		})
+	}
+}
+
+// TestRunArgsRoundTrip 生成的命令行再解析应得到相同的 ContainerSpec
+func TestRunArgsRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		spec ContainerSpec
+		// want 为空时期望与 spec 相同
+		want *ContainerSpec
+	}{
+		{
+			name: "full spec",
+			spec: ContainerSpec{
+				Image:         "registry.example.com:5000/team/app:1.2.3",
+				Name:          "app",
+				Cmd:           []string{"serve", "--config", "/etc/app/config file.yaml"},
+				Env:           []string{"A=1", "QUOTE=it's \"quoted\"", "EMPTY=", "SPACES=a b  c"},
+				WorkingDir:    "/srv",
+				User:          "1000:1000",
+				Hostname:      "app-1",
+				Labels:        map[string]string{"com.example.team": "core", "note": "x=y z"},
+				Volumes:       []string{"data:/data", "/host/path:/container/path:ro"},
+				Tmpfs:         map[string]string{"/run": "", "/tmp": "size=64m,mode=1777"},
+				RestartPolicy: "on-failure:3",
+				Memory:        "512m",
+				MemorySwap:    "1g",
+				CPUs:          1.5,
+				CPUShares:     512,
+				PidsLimit:     100,
+				Networks:      []NetworkSpec{{Name: "backend", Aliases: []string{"app", "api"}, IPv4Address: "10.0.0.5"}},
+				ExtraHosts:    []string{"host.docker.internal:host-gateway"},
+				CapAdd:        []string{"NET_ADMIN"},
+				CapDrop:       []string{"ALL"},
+				Privileged:    true,
+				AutoRemove:    true,
+				Tty:           true,
+				Interactive:   true,
+				Healthcheck: &HealthcheckSpec{
+					Test:        []string{"CMD-SHELL", "wget -qO- http://localhost:8080/health || exit 1"},
+					Interval:    "30s",
+					Timeout:     "5s",
+					StartPeriod: "10s",
+					Retries:     3,
+				},
+			},
+		},
+		{
+			name: "ports",
+			spec: ContainerSpec{
+				Image: "nginx",
+				Ports: []PortSpec{
+					{HostPort: "8080", ContainerPort: "80", Protocol: "tcp"},
+					{HostIP: "127.0.0.1", HostPort: "8443", ContainerPort: "443", Protocol: "tcp"},
+					{HostIP: "127.0.0.1", HostPort: "0", ContainerPort: "9000", Protocol: "tcp"},
+					{HostPort: "0", ContainerPort: "9001", Protocol: "tcp"},
+					{HostPort: "53", ContainerPort: "53", Protocol: "udp"},
+					{ContainerPort: "7000", Protocol: "tcp"},
+				},
+			},
+		},
+		{
+			name: "multiple networks",
+			spec: ContainerSpec{
+				Image: "postgres",
+				Networks: []NetworkSpec{
+					{Name: "backend", Aliases: []string{"db"}},
+					{Name: "monitoring", IPv4Address: "172.20.0.9"},
+				},
+			},
+		},
+		{
+			name: "entrypoint with arguments",
+			spec: ContainerSpec{Image: "alpine", Entrypoint: []string{"/bin/sh", "-c"}, Cmd: []string{"echo $HOME && ls"}},
+			want: &ContainerSpec{Image: "alpine", Entrypoint: []string{"/bin/sh"}, Cmd: []string{"-c", "echo $HOME && ls"}},
+		},
+		{
+			name: "exec form healthcheck becomes shell form",
+			spec: ContainerSpec{Image: "nginx", Healthcheck: &HealthcheckSpec{Test: []string{"CMD", "curl", "-f", "http://localhost/a b"}}},
+			want: &ContainerSpec{Image: "nginx", Healthcheck: &HealthcheckSpec{Test: []string{"CMD-SHELL", "curl -f 'http://localhost/a b'"}}},
+		},
+		{
+			name: "disabled healthcheck",
+			spec: ContainerSpec{Image: "nginx", Healthcheck: &HealthcheckSpec{Test: []string{"NONE"}}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			line := JoinCommandLine(RunArgs(&c.spec))
+			parsed, err := ParseRunCommand(line)
+			if err != nil {
+				t.Fatalf("ParseRunCommand(%q): %v", line, err)
+			}
+			want := c.spec
+			if c.want != nil {
+				want = *c.want
+			}
+			if !reflect.DeepEqual(parsed.Spec, want) {
+				t.Errorf("round trip of %q\n got %+v\nwant %+v", line, parsed.Spec, want)
+			}
+		})
+	}
+}
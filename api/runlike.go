@@ -0,0 +1,514 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RedactedValue 替换敏感环境变量值的占位符
+const RedactedValue = "<redacted>"
+
+// 名称中包含这些词时视为敏感信息，PGPASSWORD、MYSQL_PWD、JWTSECRET 这类没有分隔符的名称也能匹配
+var sensitiveEnvPattern = regexp.MustCompile(`(?i)(pass|pwd|secret|token|key|credential|auth|cert|private)`)
+
+// 包含上述词但不是敏感信息的常见名称，匹配前先去掉，AUTHOR_TOKEN 仍然会被识别
+var sensitiveEnvAllowPattern = regexp.MustCompile(`(?i)^(old)?pwd$|keyboard|keymap|author|passenger|compass|bypass`)
+
+// 匹配可以不加引号输出的参数
+var shellSafePattern = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// InspectSpec 读取容器和镜像配置，还原创建该容器时使用的 ContainerSpec
+func InspectSpec(ctx context.Context, cli *client.Client, containerID string) (*ContainerSpec, container.InspectResponse, error) {
+	inspect, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		logs.ErrorWithFields("ContainerInspect failed", map[string]interface{}{"id": containerID, "error": err})
+		return nil, inspect, err
+	}
+	var imageConfig *container.Config
+	imageInspect, err := cli.ImageInspect(ctx, inspect.Image)
+	if err == nil {
+		imageConfig = imageInspect.Config
+	} else if !client.IsErrNotFound(err) {
+		return nil, inspect, err
+	} else {
+		// 镜像已被删除时无法区分镜像默认值，保留全部配置
+		logs.WarnWithFields("Image of container not found, keeping image defaults", map[string]interface{}{"id": containerID, "image": inspect.Image})
+	}
+	return SpecFromInspect(inspect, imageConfig), inspect, nil
+}
+
+// SpecFromInspect 从 inspect 结果还原 ContainerSpec，和镜像默认值相同的配置不会保留
+func SpecFromInspect(inspect container.InspectResponse, imageConfig *container.Config) *ContainerSpec {
+	if imageConfig == nil {
+		imageConfig = &container.Config{}
+	}
+	config := inspect.Config
+	if config == nil {
+		config = &container.Config{}
+	}
+	hostConfig := inspect.HostConfig
+	if hostConfig == nil {
+		hostConfig = &container.HostConfig{}
+	}
+	spec := &ContainerSpec{
+		Image:       config.Image,
+		Name:        strings.TrimPrefix(inspect.Name, "/"),
+		Tmpfs:       hostConfig.Tmpfs,
+		ExtraHosts:  hostConfig.ExtraHosts,
+		CapAdd:      hostConfig.CapAdd,
+		CapDrop:     hostConfig.CapDrop,
+		Privileged:  hostConfig.Privileged,
+		AutoRemove:  hostConfig.AutoRemove,
+		Tty:         config.Tty,
+		Interactive: config.OpenStdin,
+	}
+
+	// 命令和入口点：覆盖入口点时镜像的 CMD 也会被清空，因此 cmd 需要一并保留
+	entrypointChanged := !reflect.DeepEqual([]string(config.Entrypoint), []string(imageConfig.Entrypoint))
+	if entrypointChanged {
+		spec.Entrypoint = config.Entrypoint
+		if len(spec.Entrypoint) == 0 {
+			spec.Entrypoint = []string{""}
+		}
+	}
+	if entrypointChanged || !reflect.DeepEqual([]string(config.Cmd), []string(imageConfig.Cmd)) {
+		spec.Cmd = config.Cmd
+	}
+
+	imageEnv := make(map[string]bool, len(imageConfig.Env))
+	for _, e := range imageConfig.Env {
+		imageEnv[e] = true
+	}
+	for _, e := range config.Env {
+		if !imageEnv[e] {
+			spec.Env = append(spec.Env, e)
+		}
+	}
+	for k, v := range config.Labels {
+		if imageValue, ok := imageConfig.Labels[k]; ok && imageValue == v {
+			continue
+		}
+		if spec.Labels == nil {
+			spec.Labels = map[string]string{}
+		}
+		spec.Labels[k] = v
+	}
+	if config.WorkingDir != imageConfig.WorkingDir {
+		spec.WorkingDir = config.WorkingDir
+	}
+	if config.User != imageConfig.User {
+		spec.User = config.User
+	}
+	// 默认主机名是容器ID的前12位
+	if config.Hostname != "" && !strings.HasPrefix(inspect.ID, config.Hostname) {
+		spec.Hostname = config.Hostname
+	}
+	if config.Healthcheck != nil && !reflect.DeepEqual(config.Healthcheck, imageConfig.Healthcheck) {
+		spec.Healthcheck = &HealthcheckSpec{
+			Test:    config.Healthcheck.Test,
+			Retries: config.Healthcheck.Retries,
+		}
+		if config.Healthcheck.Interval > 0 {
+			spec.Healthcheck.Interval = config.Healthcheck.Interval.String()
+		}
+		if config.Healthcheck.Timeout > 0 {
+			spec.Healthcheck.Timeout = config.Healthcheck.Timeout.String()
+		}
+		if config.Healthcheck.StartPeriod > 0 {
+			spec.Healthcheck.StartPeriod = config.Healthcheck.StartPeriod.String()
+		}
+	}
+
+	// 端口：先输出映射，再输出只暴露不映射且不是镜像默认暴露的端口
+	ports := make([]string, 0, len(hostConfig.PortBindings))
+	for port := range hostConfig.PortBindings {
+		ports = append(ports, string(port))
+	}
+	sort.Strings(ports)
+	for _, p := range ports {
+		port := nat.Port(p)
+		for _, binding := range hostConfig.PortBindings[port] {
+			hostPort := binding.HostPort
+			if hostPort == "" {
+				hostPort = "0"
+			}
+			spec.Ports = append(spec.Ports, PortSpec{
+				HostIP:        binding.HostIP,
+				HostPort:      hostPort,
+				ContainerPort: port.Port(),
+				Protocol:      port.Proto(),
+			})
+		}
+	}
+	exposed := make([]string, 0)
+	for port := range config.ExposedPorts {
+		if _, ok := imageConfig.ExposedPorts[port]; ok {
+			continue
+		}
+		if len(hostConfig.PortBindings[port]) > 0 {
+			continue
+		}
+		exposed = append(exposed, string(port))
+	}
+	sort.Strings(exposed)
+	for _, p := range exposed {
+		port := nat.Port(p)
+		spec.Ports = append(spec.Ports, PortSpec{ContainerPort: port.Port(), Protocol: port.Proto()})
+	}
+
+	// 卷：Binds 保留原始写法，--mount 转换为同等的写法，匿名卷只保留镜像未声明的
+	spec.Volumes = append(spec.Volumes, hostConfig.Binds...)
+	for _, m := range hostConfig.Mounts {
+		switch m.Type {
+		case "bind", "volume":
+			if m.Source == "" {
+				spec.Volumes = append(spec.Volumes, m.Target)
+				continue
+			}
+			volume := m.Source + ":" + m.Target
+			if m.ReadOnly {
+				volume += ":ro"
+			}
+			spec.Volumes = append(spec.Volumes, volume)
+		case "tmpfs":
+			if spec.Tmpfs == nil {
+				spec.Tmpfs = map[string]string{}
+			}
+			// 与 --mount type=tmpfs,tmpfs-size=...,tmpfs-mode=... 的解析结果一致
+			var options []string
+			if m.TmpfsOptions != nil {
+				if m.TmpfsOptions.SizeBytes > 0 {
+					options = append(options, "size="+strconv.FormatInt(m.TmpfsOptions.SizeBytes, 10))
+				}
+				if mode := m.TmpfsOptions.Mode; mode != 0 {
+					// docker 直接把八进制的 tmpfs-mode 存为 FileMode，粘滞位在 01000 而不是 os.ModeSticky
+					bits := uint64(mode & 07777)
+					if mode&os.ModeSticky != 0 {
+						bits |= 01000
+					}
+					options = append(options, "mode="+strconv.FormatUint(bits, 8))
+				}
+			}
+			spec.Tmpfs[m.Target] = strings.Join(options, ",")
+		}
+	}
+	anonymous := make([]string, 0)
+	for dest := range config.Volumes {
+		if _, ok := imageConfig.Volumes[dest]; ok {
+			continue
+		}
+		if volumeTargets(spec.Volumes)[dest] {
+			continue
+		}
+		anonymous = append(anonymous, dest)
+	}
+	sort.Strings(anonymous)
+	spec.Volumes = append(spec.Volumes, anonymous...)
+
+	if name := hostConfig.RestartPolicy.Name; name != "" && name != container.RestartPolicyDisabled {
+		spec.RestartPolicy = string(name)
+		if hostConfig.RestartPolicy.MaximumRetryCount > 0 {
+			spec.RestartPolicy += ":" + strconv.Itoa(hostConfig.RestartPolicy.MaximumRetryCount)
+		}
+	}
+	if hostConfig.Memory > 0 {
+		spec.Memory = formatBytes(hostConfig.Memory)
+	}
+	if hostConfig.MemorySwap == -1 {
+		spec.MemorySwap = "-1"
+	} else if hostConfig.MemorySwap > 0 {
+		spec.MemorySwap = formatBytes(hostConfig.MemorySwap)
+	}
+	if hostConfig.NanoCPUs > 0 {
+		spec.CPUs = float64(hostConfig.NanoCPUs) / 1e9
+	}
+	spec.CPUShares = hostConfig.CPUShares
+	if hostConfig.PidsLimit != nil && *hostConfig.PidsLimit != 0 {
+		spec.PidsLimit = *hostConfig.PidsLimit
+	}
+
+	spec.Networks = networksFromInspect(inspect)
+	return spec
+}
+
+// networksFromInspect 还原网络连接，NetworkMode 对应的网络排在第一位，默认 bridge 网络不输出
+func networksFromInspect(inspect container.InspectResponse) []NetworkSpec {
+	mode := ""
+	if inspect.HostConfig != nil {
+		mode = string(inspect.HostConfig.NetworkMode)
+	}
+	if inspect.NetworkSettings == nil {
+		if mode != "" && mode != "default" && mode != "bridge" {
+			return []NetworkSpec{{Name: mode}}
+		}
+		return nil
+	}
+	names := make([]string, 0, len(inspect.NetworkSettings.Networks))
+	for name := range inspect.NetworkSettings.Networks {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == mode || names[j] == mode {
+			return names[i] == mode
+		}
+		return names[i] < names[j]
+	})
+	if (mode == "" || mode == "default" || mode == "bridge") && len(names) == 1 && names[0] == "bridge" {
+		return nil
+	}
+	// container:<id> 之类的模式没有独立的网络配置
+	if strings.HasPrefix(mode, "container:") {
+		return []NetworkSpec{{Name: mode}}
+	}
+	shortID := inspect.ID
+	if len(shortID) > 12 {
+		shortID = shortID[:12]
+	}
+	containerName := strings.TrimPrefix(inspect.Name, "/")
+	networks := make([]NetworkSpec, 0, len(names))
+	for _, name := range names {
+		endpoint := inspect.NetworkSettings.Networks[name]
+		n := NetworkSpec{Name: name}
+		if endpoint != nil {
+			// 容器短ID和容器名是 Docker 自动添加的别名
+			for _, alias := range endpoint.Aliases {
+				if alias != shortID && alias != containerName {
+					n.Aliases = append(n.Aliases, alias)
+				}
+			}
+			if endpoint.IPAMConfig != nil {
+				n.IPv4Address = endpoint.IPAMConfig.IPv4Address
+			}
+		}
+		networks = append(networks, n)
+	}
+	return networks
+}
+
+// RedactEnv 把名称看起来敏感的环境变量值替换为占位符，返回被替换的变量名
+func RedactEnv(spec *ContainerSpec) []string {
+	redacted := make([]string, 0)
+	for i, e := range spec.Env {
+		key, _, _ := strings.Cut(e, "=")
+		if isSensitiveEnv(key) {
+			spec.Env[i] = key + "=" + RedactedValue
+			redacted = append(redacted, key)
+		}
+	}
+	return redacted
+}
+
+func isSensitiveEnv(name string) bool {
+	return sensitiveEnvPattern.MatchString(sensitiveEnvAllowPattern.ReplaceAllString(name, ""))
+}
+
+// RunArgs 把 ContainerSpec 转换为 docker run 的参数列表，结果可以被 ParseRunCommand 解析回来
+func RunArgs(spec *ContainerSpec) []string {
+	args := []string{"docker", "run", "-d"}
+	if spec.Name != "" {
+		args = append(args, "--name", spec.Name)
+	}
+	if spec.Hostname != "" {
+		args = append(args, "--hostname", spec.Hostname)
+	}
+	if spec.User != "" {
+		args = append(args, "--user", spec.User)
+	}
+	if spec.WorkingDir != "" {
+		args = append(args, "--workdir", spec.WorkingDir)
+	}
+	for _, e := range spec.Env {
+		args = append(args, "-e", e)
+	}
+	for _, k := range sortedKeys(spec.Labels) {
+		args = append(args, "--label", k+"="+spec.Labels[k])
+	}
+	for _, p := range spec.Ports {
+		port := p.ContainerPort
+		if p.Protocol != "" && p.Protocol != "tcp" && !strings.Contains(port, "/") {
+			port += "/" + p.Protocol
+		}
+		switch {
+		case p.HostPort == "" && p.HostIP == "":
+			args = append(args, "--expose", port)
+		case p.HostPort == "0" || p.HostPort == "":
+			if p.HostIP == "" {
+				args = append(args, "-p", port)
+			} else {
+				args = append(args, "-p", p.HostIP+"::"+port)
+			}
+		case p.HostIP == "":
+			args = append(args, "-p", p.HostPort+":"+port)
+		default:
+			args = append(args, "-p", p.HostIP+":"+p.HostPort+":"+port)
+		}
+	}
+	for _, v := range spec.Volumes {
+		args = append(args, "-v", v)
+	}
+	for _, k := range sortedKeys(spec.Tmpfs) {
+		if spec.Tmpfs[k] == "" {
+			args = append(args, "--tmpfs", k)
+		} else {
+			args = append(args, "--tmpfs", k+":"+spec.Tmpfs[k])
+		}
+	}
+	if spec.RestartPolicy != "" {
+		args = append(args, "--restart", spec.RestartPolicy)
+	}
+	if spec.Memory != "" {
+		args = append(args, "--memory", spec.Memory)
+	}
+	if spec.MemorySwap != "" {
+		args = append(args, "--memory-swap", spec.MemorySwap)
+	}
+	if spec.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(spec.CPUs, 'f', -1, 64))
+	}
+	if spec.CPUShares > 0 {
+		args = append(args, "--cpu-shares", strconv.FormatInt(spec.CPUShares, 10))
+	}
+	if spec.PidsLimit != 0 {
+		args = append(args, "--pids-limit", strconv.FormatInt(spec.PidsLimit, 10))
+	}
+	if len(spec.Networks) == 1 {
+		n := spec.Networks[0]
+		args = append(args, "--network", n.Name)
+		for _, alias := range n.Aliases {
+			args = append(args, "--network-alias", alias)
+		}
+		if n.IPv4Address != "" {
+			args = append(args, "--ip", n.IPv4Address)
+		}
+	} else {
+		// 多个网络使用 --network name=...,alias=... 的写法
+		for _, n := range spec.Networks {
+			value := "name=" + n.Name
+			for _, alias := range n.Aliases {
+				value += ",alias=" + alias
+			}
+			if n.IPv4Address != "" {
+				value += ",ip=" + n.IPv4Address
+			}
+			args = append(args, "--network", value)
+		}
+	}
+	for _, h := range spec.ExtraHosts {
+		args = append(args, "--add-host", h)
+	}
+	for _, c := range spec.CapAdd {
+		args = append(args, "--cap-add", c)
+	}
+	for _, c := range spec.CapDrop {
+		args = append(args, "--cap-drop", c)
+	}
+	if spec.Privileged {
+		args = append(args, "--privileged")
+	}
+	if spec.AutoRemove {
+		args = append(args, "--rm")
+	}
+	if spec.Interactive {
+		args = append(args, "-i")
+	}
+	if spec.Tty {
+		args = append(args, "-t")
+	}
+	if h := spec.Healthcheck; h != nil {
+		switch {
+		case len(h.Test) > 0 && h.Test[0] == "NONE":
+			args = append(args, "--no-healthcheck")
+		case len(h.Test) > 1 && h.Test[0] == "CMD-SHELL":
+			args = append(args, "--health-cmd", strings.Join(h.Test[1:], " "))
+		case len(h.Test) > 1 && h.Test[0] == "CMD":
+			// docker run 的 --health-cmd 只有 shell 形式，与 docker 一样把 CMD 的参数拼成命令行
+			args = append(args, "--health-cmd", JoinCommandLine(h.Test[1:]))
+		case len(h.Test) > 0:
+			args = append(args, "--health-cmd", JoinCommandLine(h.Test))
+		}
+		if h.Interval != "" {
+			args = append(args, "--health-interval", h.Interval)
+		}
+		if h.Timeout != "" {
+			args = append(args, "--health-timeout", h.Timeout)
+		}
+		if h.StartPeriod != "" {
+			args = append(args, "--health-start-period", h.StartPeriod)
+		}
+		if h.Retries > 0 {
+			args = append(args, "--health-retries", strconv.Itoa(h.Retries))
+		}
+	}
+	// --entrypoint 只接受一个值，多出来的部分放到命令前面
+	cmd := spec.Cmd
+	if len(spec.Entrypoint) > 0 {
+		args = append(args, "--entrypoint", spec.Entrypoint[0])
+		if len(spec.Entrypoint) > 1 {
+			cmd = append(append([]string{}, spec.Entrypoint[1:]...), cmd...)
+		}
+	}
+	args = append(args, spec.Image)
+	return append(args, cmd...)
+}
+
+// JoinCommandLine 按 shell 规则给参数加引号后拼接成命令行
+func JoinCommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(s string) string {
+	if shellSafePattern.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+func volumeTargets(volumes []string) map[string]bool {
+	targets := make(map[string]bool, len(volumes))
+	for _, v := range volumes {
+		parts := strings.Split(v, ":")
+		if len(parts) == 1 {
+			targets[parts[0]] = true
+		} else {
+			targets[parts[1]] = true
+		}
+	}
+	return targets
+}
+
+// formatBytes 转换为 docker 参数接受的最简写法，例如 512m、2g
+func formatBytes(n int64) string {
+	switch {
+	case n%(1<<30) == 0:
+		return fmt.Sprintf("%dg", n>>30)
+	case n%(1<<20) == 0:
+		return fmt.Sprintf("%dm", n>>20)
+	case n%(1<<10) == 0:
+		return fmt.Sprintf("%dk", n>>10)
+	default:
+		return strconv.FormatInt(n, 10)
+	}
+}
+
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,43 @@
+package api
+
+import "testing"
+
+func TestIsSensitiveEnv(t *testing.T) {
+	cases := []struct {
+		name string
+		want bool
+	}{
+		{"PASSWORD", true},
+		{"DB_PASSWORD", true},
+		{"PGPASSWORD", true},
+		{"DBPASSWORD", true},
+		{"MYSQL_PWD", true},
+		{"MYSQL_ROOT_PASSWORD_FILE", true},
+		{"JWTSECRET", true},
+		{"GITHUB_TOKEN", true},
+		{"GITHUB_TOKEN2", true},
+		{"AWS_SECRET_ACCESS_KEY_ID", true},
+		{"API_KEY", true},
+		{"apikey", true},
+		{"NPM_AUTH", true},
+		{"GOOGLE_APPLICATION_CREDENTIALS", true},
+		{"TLS_CERT", true},
+		{"PRIVATE_DATA", true},
+		{"AUTHOR_TOKEN", true},
+		{"PATH", false},
+		{"HOME", false},
+		{"PWD", false},
+		{"OLDPWD", false},
+		{"KEYBOARD_LAYOUT", false},
+		{"XKB_KEYMAP", false},
+		{"AUTHOR", false},
+		{"PASSENGER_APP_ENV", false},
+		{"NGINX_VERSION", false},
+		{"LANG", false},
+	}
+	for _, c := range cases {
+		if got := isSensitiveEnv(c.name); got != c.want {
+			t.Errorf("isSensitiveEnv(%q) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/client"
+	"strconv"
+)
+
+// ImageSearchOptions 对应 docker search 的参数
+type ImageSearchOptions struct {
+	Term     string `json:"term"`
+	Limit    int    `json:"limit,omitempty"`
+	Official *bool  `json:"official,omitempty"`
+	Stars    int    `json:"stars,omitempty"`
+}
+
+// SearchImages 通过守护进程在仓库中搜索镜像，默认是 Docker Hub
+func SearchImages(ctx context.Context, cli *client.Client, opts ImageSearchOptions) ([]resp.ImageSearchResult, error) {
+	args := filters.NewArgs()
+	if opts.Official != nil {
+		args.Add("is-official", strconv.FormatBool(*opts.Official))
+	}
+	if opts.Stars > 0 {
+		args.Add("stars", strconv.Itoa(opts.Stars))
+	}
+	// 搜索词不一定是合法的镜像引用，解析失败时按 Docker Hub 处理
+	host, err := ImageRegistryHost(opts.Term)
+	if err != nil {
+		host = DockerHubHost
+	}
+	auth := ""
+	if config, ok := Credentials.Get(host); ok {
+		if auth, err = registry.EncodeAuthConfig(config); err != nil {
+			return nil, err
+		}
+	}
+	list, err := cli.ImageSearch(ctx, opts.Term, registry.SearchOptions{RegistryAuth: auth, Filters: args, Limit: opts.Limit})
+	if err != nil {
+		logs.ErrorWithFields("ImageSearch failed", map[string]interface{}{"term": opts.Term, "error": err})
+		return nil, err
+	}
+	results := make([]resp.ImageSearchResult, 0, len(list))
+	for _, item := range list {
+		results = append(results, resp.ImageSearchResult{
+			Name:        item.Name,
+			Description: item.Description,
+			Stars:       item.StarCount,
+			Official:    item.IsOfficial,
+		})
+	}
+	return results, nil
+}
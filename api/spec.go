@@ -0,0 +1,277 @@
+package api
+
+import (
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContainerSpec 创建容器的完整配置，字段与 docker run 的参数一一对应
+type ContainerSpec struct {
+	Image         string            `json:"image"`
+	Name          string            `json:"name,omitempty"`
+	Cmd           []string          `json:"cmd,omitempty"`
+	Entrypoint    []string          `json:"entrypoint,omitempty"`
+	Env           []string          `json:"env,omitempty"`
+	WorkingDir    string            `json:"workingDir,omitempty"`
+	User          string            `json:"user,omitempty"`
+	Hostname      string            `json:"hostname,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Ports         []PortSpec        `json:"ports,omitempty"`
+	Volumes       []string          `json:"volumes,omitempty"`
+	Tmpfs         map[string]string `json:"tmpfs,omitempty"`
+	RestartPolicy string            `json:"restartPolicy,omitempty"`
+	Memory        string            `json:"memory,omitempty"`
+	MemorySwap    string            `json:"memorySwap,omitempty"`
+	CPUs          float64           `json:"cpus,omitempty"`
+	CPUShares     int64             `json:"cpuShares,omitempty"`
+	PidsLimit     int64             `json:"pidsLimit,omitempty"`
+	Networks      []NetworkSpec     `json:"networks,omitempty"`
+	ExtraHosts    []string          `json:"extraHosts,omitempty"`
+	CapAdd        []string          `json:"capAdd,omitempty"`
+	CapDrop       []string          `json:"capDrop,omitempty"`
+	Privileged    bool              `json:"privileged,omitempty"`
+	Healthcheck   *HealthcheckSpec  `json:"healthcheck,omitempty"`
+	AutoRemove    bool              `json:"autoRemove,omitempty"`
+	Tty           bool              `json:"tty,omitempty"`
+	Interactive   bool              `json:"interactive,omitempty"`
+}
+
+type PortSpec struct {
+	HostIP        string `json:"hostIp,omitempty"`
+	HostPort      string `json:"hostPort,omitempty"`
+	ContainerPort string `json:"containerPort"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+type NetworkSpec struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases,omitempty"`
+	IPv4Address string   `json:"ipv4Address,omitempty"`
+}
+
+type HealthcheckSpec struct {
+	Test        []string `json:"test,omitempty"`
+	Interval    string   `json:"interval,omitempty"`
+	Timeout     string   `json:"timeout,omitempty"`
+	StartPeriod string   `json:"startPeriod,omitempty"`
+	Retries     int      `json:"retries,omitempty"`
+}
+
+// Build 把 ContainerSpec 转换为 ContainerCreate 需要的三份配置，网络只包含第一个，其余的在创建后连接
+func (s *ContainerSpec) Build() (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	if s.Image == "" {
+		return nil, nil, nil, fmt.Errorf("image is required")
+	}
+	exposedPorts, portBindings, err := buildPorts(s.Ports)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	containerVolumes, binds := buildVolumes(s.Volumes)
+	restartPolicy, err := ParseRestartPolicy(s.RestartPolicy)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if s.AutoRemove && restartPolicy.Name != "" && restartPolicy.Name != container.RestartPolicyDisabled {
+		return nil, nil, nil, fmt.Errorf("autoRemove conflicts with restart policy %q", s.RestartPolicy)
+	}
+	healthcheck, err := s.Healthcheck.build()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	resources := container.Resources{
+		NanoCPUs:  int64(s.CPUs * 1e9),
+		CPUShares: s.CPUShares,
+	}
+	if s.Memory != "" {
+		if resources.Memory, err = units.RAMInBytes(s.Memory); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid memory %q: %v", s.Memory, err)
+		}
+	}
+	if s.MemorySwap != "" {
+		// -1 表示不限制swap
+		if s.MemorySwap == "-1" {
+			resources.MemorySwap = -1
+		} else if resources.MemorySwap, err = units.RAMInBytes(s.MemorySwap); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid memorySwap %q: %v", s.MemorySwap, err)
+		}
+	}
+	if s.PidsLimit != 0 {
+		pidsLimit := s.PidsLimit
+		resources.PidsLimit = &pidsLimit
+	}
+
+	config := &container.Config{
+		Image:        s.Image,
+		Cmd:          strslice.StrSlice(s.Cmd),
+		Entrypoint:   strslice.StrSlice(s.Entrypoint),
+		Env:          s.Env,
+		WorkingDir:   s.WorkingDir,
+		User:         s.User,
+		Hostname:     s.Hostname,
+		Labels:       s.Labels,
+		ExposedPorts: exposedPorts,
+		Volumes:      containerVolumes,
+		Healthcheck:  healthcheck,
+		Tty:          s.Tty,
+		OpenStdin:    s.Interactive,
+	}
+	hostConfig := &container.HostConfig{
+		PortBindings:  portBindings,
+		Binds:         binds,
+		Tmpfs:         s.Tmpfs,
+		RestartPolicy: restartPolicy,
+		Resources:     resources,
+		ExtraHosts:    s.ExtraHosts,
+		CapAdd:        s.CapAdd,
+		CapDrop:       s.CapDrop,
+		Privileged:    s.Privileged,
+		AutoRemove:    s.AutoRemove,
+	}
+	var networkingConfig *network.NetworkingConfig
+	if len(s.Networks) > 0 {
+		first := s.Networks[0]
+		if first.Name == "" {
+			return nil, nil, nil, fmt.Errorf("network name is required")
+		}
+		hostConfig.NetworkMode = container.NetworkMode(first.Name)
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				first.Name: first.endpoint(),
+			},
+		}
+	}
+	return config, hostConfig, networkingConfig, nil
+}
+
+func (n NetworkSpec) endpoint() *network.EndpointSettings {
+	endpoint := &network.EndpointSettings{
+		Aliases: n.Aliases,
+	}
+	if n.IPv4Address != "" {
+		endpoint.IPAMConfig = &network.EndpointIPAMConfig{
+			IPv4Address: n.IPv4Address,
+		}
+	}
+	return endpoint
+}
+
+func (h *HealthcheckSpec) build() (*container.HealthConfig, error) {
+	if h == nil {
+		return nil, nil
+	}
+	test := h.Test
+	// 未指定类型时，单条命令按 CMD-SHELL 执行，多条按 CMD 执行
+	if len(test) > 0 && test[0] != "NONE" && test[0] != "CMD" && test[0] != "CMD-SHELL" {
+		if len(test) == 1 {
+			test = []string{"CMD-SHELL", test[0]}
+		} else {
+			test = append([]string{"CMD"}, test...)
+		}
+	}
+	health := &container.HealthConfig{
+		Test:    test,
+		Retries: h.Retries,
+	}
+	durations := []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"interval", h.Interval, &health.Interval},
+		{"timeout", h.Timeout, &health.Timeout},
+		{"startPeriod", h.StartPeriod, &health.StartPeriod},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid healthcheck %s %q: %v", d.name, d.value, err)
+		}
+		*d.dst = parsed
+	}
+	return health, nil
+}
+
+// ParseRestartPolicy 解析 no、always、unless-stopped、on-failure[:max-retries]
+func ParseRestartPolicy(policy string) (container.RestartPolicy, error) {
+	if policy == "" {
+		return container.RestartPolicy{}, nil
+	}
+	name, retries, hasRetries := strings.Cut(policy, ":")
+	restartPolicy := container.RestartPolicy{Name: container.RestartPolicyMode(name)}
+	if hasRetries {
+		count, err := strconv.Atoi(retries)
+		if err != nil {
+			return restartPolicy, fmt.Errorf("invalid restart policy %q: maximum retry count must be an integer", policy)
+		}
+		restartPolicy.MaximumRetryCount = count
+	}
+	if err := container.ValidateRestartPolicy(restartPolicy); err != nil {
+		return restartPolicy, fmt.Errorf("invalid restart policy %q: %v", policy, err)
+	}
+	return restartPolicy, nil
+}
+
+// buildPorts 返回暴露的端口和端口映射，hostIp 和 hostPort 都未指定时只暴露不映射
+func buildPorts(ports []PortSpec) (nat.PortSet, nat.PortMap, error) {
+	exposedPorts := nat.PortSet{}
+	portBindings := nat.PortMap{}
+	for _, p := range ports {
+		containerPort, proto, hasProto := strings.Cut(p.ContainerPort, "/")
+		if !hasProto {
+			proto = p.Protocol
+		}
+		if proto == "" {
+			proto = "tcp"
+		}
+		port, err := nat.NewPort(proto, containerPort)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid container port %q: %v", p.ContainerPort, err)
+		}
+		exposedPorts[port] = struct{}{}
+		if p.HostPort == "" && p.HostIP == "" {
+			if _, ok := portBindings[port]; !ok {
+				portBindings[port] = []nat.PortBinding{}
+			}
+			continue
+		}
+		// hostPort 为 0 时由 Docker 分配空闲端口
+		hostPort := p.HostPort
+		if hostPort == "0" {
+			hostPort = ""
+		}
+		portBindings[port] = append(portBindings[port], nat.PortBinding{
+			HostIP:   p.HostIP,
+			HostPort: hostPort,
+		})
+	}
+	return exposedPorts, portBindings, nil
+}
+
+// buildVolumes 只有容器路径的条目作为匿名卷，其余按 source:target[:mode] 交给 Docker 解析
+func buildVolumes(volumes []string) (map[string]struct{}, []string) {
+	containerVolumes := make(map[string]struct{})
+	var binds []string
+	for _, vol := range volumes {
+		vol = strings.TrimSpace(vol)
+		if vol == "" {
+			continue
+		}
+		if !strings.Contains(vol, ":") {
+			containerVolumes[vol] = struct{}{}
+			continue
+		}
+		binds = append(binds, vol)
+	}
+	return containerVolumes, binds
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"fmt"
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+)
+
+// NormalizeTag 校验并规范化要写入的镜像引用，不能带摘要，未指定标签时使用 latest
+func NormalizeTag(ref string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid image reference %q: %v", ref, err)
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return "", fmt.Errorf("invalid image reference %q: tags can't contain a digest", ref)
+	}
+	return reference.FamiliarString(reference.TagNameOnly(named)), nil
+}
+
+// TagImage 给 source 指向的镜像添加一个或多个引用，removeSource 为 true 时随后删除 source 这个标签（用于提升构建版本）
+func TagImage(ctx context.Context, cli *client.Client, source string, targets []string, removeSource bool) (*resp.ImageTag, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("at least one target reference is required")
+	}
+	normalized := make([]string, 0, len(targets))
+	for _, target := range targets {
+		ref, err := NormalizeTag(target)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, ref)
+	}
+	// 删除源标签时 source 必须是标签而不是镜像ID
+	if removeSource {
+		ref, err := NormalizeTag(source)
+		if err != nil {
+			return nil, fmt.Errorf("source must be a tag to remove it: %v", err)
+		}
+		source = ref
+	}
+	inspect, err := cli.ImageInspect(ctx, source)
+	if err != nil {
+		logs.ErrorWithFields("ImageInspect failed", map[string]interface{}{"image": source, "error": err})
+		return nil, err
+	}
+	result := &resp.ImageTag{Source: source, ImageID: inspect.ID}
+	for _, target := range normalized {
+		if err := cli.ImageTag(ctx, inspect.ID, target); err != nil {
+			logs.ErrorWithFields("ImageTag failed", map[string]interface{}{"image": inspect.ID, "target": target, "error": err})
+			return result, err
+		}
+		result.Added = append(result.Added, target)
+	}
+	if removeSource {
+		for _, target := range normalized {
+			// 目标与源相同时不能删除
+			if target == source {
+				return result, nil
+			}
+		}
+		removed, err := UntagImage(ctx, cli, source)
+		if err != nil {
+			return result, fmt.Errorf("tagged %v but failed to remove %s: %v", result.Added, source, err)
+		}
+		result.Removed = removed
+	}
+	logs.InfoWithFields("ImageTag success", map[string]interface{}{"image": inspect.ID, "added": result.Added, "removed": result.Removed})
+	return result, nil
+}
+
+// UntagImage 删除镜像的一个标签，镜像还有其他引用时只移除该标签，不会强制删除
+func UntagImage(ctx context.Context, cli *client.Client, ref string) ([]string, error) {
+	normalized, err := NormalizeTag(ref)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := cli.ImageRemove(ctx, normalized, image.RemoveOptions{PruneChildren: true})
+	if err != nil {
+		logs.ErrorWithFields("ImageRemove failed", map[string]interface{}{"image": normalized, "error": err})
+		return nil, err
+	}
+	removed := make([]string, 0, len(responses))
+	for _, r := range responses {
+		if r.Untagged != "" {
+			removed = append(removed, r.Untagged)
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-units"
+	"strings"
+)
+
+// UpdateOptions 可在线修改的资源限制，未设置的字段保持不变
+type UpdateOptions struct {
+	Memory            string   `json:"memory,omitempty"`
+	MemorySwap        string   `json:"memorySwap,omitempty"`
+	MemoryReservation string   `json:"memoryReservation,omitempty"`
+	CPUShares         *int64   `json:"cpuShares,omitempty"`
+	CPUs              *float64 `json:"cpus,omitempty"`
+	CPUPeriod         *int64   `json:"cpuPeriod,omitempty"`
+	CPUQuota          *int64   `json:"cpuQuota,omitempty"`
+	CpusetCpus        *string  `json:"cpusetCpus,omitempty"`
+	CpusetMems        *string  `json:"cpusetMems,omitempty"`
+	PidsLimit         *int64   `json:"pidsLimit,omitempty"`
+	BlkioWeight       *uint16  `json:"blkioWeight,omitempty"`
+	RestartPolicy     string   `json:"restartPolicy,omitempty"`
+}
+
+func (o UpdateOptions) build() (container.UpdateConfig, error) {
+	var update container.UpdateConfig
+	var err error
+	sizes := []struct {
+		name  string
+		value string
+		dst   *int64
+	}{
+		{"memory", o.Memory, &update.Memory},
+		{"memorySwap", o.MemorySwap, &update.MemorySwap},
+		{"memoryReservation", o.MemoryReservation, &update.MemoryReservation},
+	}
+	for _, s := range sizes {
+		if s.value == "" {
+			continue
+		}
+		// -1 表示不限制swap
+		if s.value == "-1" {
+			*s.dst = -1
+			continue
+		}
+		if *s.dst, err = units.RAMInBytes(s.value); err != nil {
+			return update, fmt.Errorf("invalid %s %q: %v", s.name, s.value, err)
+		}
+	}
+	if o.CPUShares != nil {
+		update.CPUShares = *o.CPUShares
+	}
+	if o.CPUs != nil {
+		if o.CPUPeriod != nil || o.CPUQuota != nil {
+			return update, fmt.Errorf("cpus conflicts with cpuPeriod and cpuQuota")
+		}
+		// ContainerUpdate 把 0 当作不修改，无法通过 0 取消限制
+		if *o.CPUs <= 0 {
+			return update, fmt.Errorf("cpus must be greater than 0")
+		}
+		update.NanoCPUs = int64(*o.CPUs * 1e9)
+	}
+	if o.CPUPeriod != nil {
+		update.CPUPeriod = *o.CPUPeriod
+	}
+	if o.CPUQuota != nil {
+		update.CPUQuota = *o.CPUQuota
+	}
+	if o.CpusetCpus != nil {
+		update.CpusetCpus = *o.CpusetCpus
+	}
+	if o.CpusetMems != nil {
+		update.CpusetMems = *o.CpusetMems
+	}
+	if o.PidsLimit != nil {
+		pidsLimit := *o.PidsLimit
+		update.PidsLimit = &pidsLimit
+	}
+	if o.BlkioWeight != nil {
+		// 与 cpus 一样 0 会被当作不修改，直接拒绝
+		if *o.BlkioWeight < 10 || *o.BlkioWeight > 1000 {
+			return update, fmt.Errorf("blkioWeight must be between 10 and 1000")
+		}
+		update.BlkioWeight = *o.BlkioWeight
+	}
+	if o.RestartPolicy != "" {
+		if update.RestartPolicy, err = ParseRestartPolicy(o.RestartPolicy); err != nil {
+			return update, err
+		}
+	}
+	return update, nil
+}
+
+// ContainerUpdate 在线修改容器的资源限制和重启策略，返回修改前后的值
+func ContainerUpdate(ctx context.Context, cli *client.Client, containerID string, opts UpdateOptions) (*resp.ContainerUpdate, error) {
+	update, err := opts.build()
+	if err != nil {
+		return nil, err
+	}
+	before, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		logs.ErrorWithFields("ContainerInspect failed", map[string]interface{}{"id": containerID, "error": err})
+		return nil, err
+	}
+	updated, err := cli.ContainerUpdate(ctx, before.ID, update)
+	if err != nil {
+		logs.ErrorWithFields("ContainerUpdate failed", map[string]interface{}{"id": before.ID, "error": err})
+		return nil, err
+	}
+	after, err := cli.ContainerInspect(ctx, before.ID)
+	if err != nil {
+		logs.ErrorWithFields("ContainerInspect failed", map[string]interface{}{"id": before.ID, "error": err})
+		return nil, err
+	}
+	result := &resp.ContainerUpdate{
+		ID:       before.ID,
+		Name:     strings.TrimPrefix(before.Name, "/"),
+		Before:   containerResources(before.HostConfig),
+		After:    containerResources(after.HostConfig),
+		Warnings: updated.Warnings,
+	}
+	result.Changed = changedResources(result.Before, result.After)
+	logs.InfoWithFields("ContainerUpdate success", map[string]interface{}{"id": before.ID, "changed": result.Changed})
+	return result, nil
+}
+
+func containerResources(hostConfig *container.HostConfig) resp.ContainerResources {
+	if hostConfig == nil {
+		return resp.ContainerResources{}
+	}
+	r := hostConfig.Resources
+	res := resp.ContainerResources{
+		Memory:            r.Memory,
+		MemorySwap:        r.MemorySwap,
+		MemoryReservation: r.MemoryReservation,
+		CPUShares:         r.CPUShares,
+		CPUs:              float64(r.NanoCPUs) / 1e9,
+		CPUPeriod:         r.CPUPeriod,
+		CPUQuota:          r.CPUQuota,
+		CpusetCpus:        r.CpusetCpus,
+		CpusetMems:        r.CpusetMems,
+		BlkioWeight:       r.BlkioWeight,
+		RestartPolicy:     string(hostConfig.RestartPolicy.Name),
+	}
+	if r.PidsLimit != nil {
+		res.PidsLimit = *r.PidsLimit
+	}
+	if hostConfig.RestartPolicy.MaximumRetryCount > 0 {
+		res.RestartPolicy = fmt.Sprintf("%s:%d", res.RestartPolicy, hostConfig.RestartPolicy.MaximumRetryCount)
+	}
+	return res
+}
+
+func changedResources(before, after resp.ContainerResources) []string {
+	changed := make([]string, 0)
+	fields := []struct {
+		name string
+		same bool
+	}{
+		{"memory", before.Memory == after.Memory},
+		{"memorySwap", before.MemorySwap == after.MemorySwap},
+		{"memoryReservation", before.MemoryReservation == after.MemoryReservation},
+		{"cpuShares", before.CPUShares == after.CPUShares},
+		{"cpus", before.CPUs == after.CPUs},
+		{"cpuPeriod", before.CPUPeriod == after.CPUPeriod},
+		{"cpuQuota", before.CPUQuota == after.CPUQuota},
+		{"cpusetCpus", before.CpusetCpus == after.CpusetCpus},
+		{"cpusetMems", before.CpusetMems == after.CpusetMems},
+		{"pidsLimit", before.PidsLimit == after.PidsLimit},
+		{"blkioWeight", before.BlkioWeight == after.BlkioWeight},
+		{"restartPolicy", before.RestartPolicy == after.RestartPolicy},
+	}
+	for _, f := range fields {
+		if !f.same {
+			changed = append(changed, f.name)
+		}
+	}
+	return changed
+}
@@ -7,8 +7,9 @@ import (
 )
 
 type Config struct {
-	Path     string
-	CertPath string
+	Path       string
+	CertPath   string
+	ArchiveDir string
 }
 
 // 从命令行参数获取数据库配置
@@ -18,6 +19,7 @@ func GetConfigFromArgs() (*Config, error) {
 	//"tcp://101.126.149.147:2375"
 	flag.StringVar(&config.Path, "path", os.Getenv("DOCKER_PATH"), "docker addr")
 	flag.StringVar(&config.CertPath, "cert", os.Getenv("DOCKER_CERT"), "docker addr")
+	flag.StringVar(&config.ArchiveDir, "archive-dir", os.Getenv("DOCKER_ARCHIVE_DIR"), "directory for exported archives")
 
 	// 解析命令行参数
 	flag.Parse()
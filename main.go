@@ -28,7 +28,7 @@ func main() {
 	}
 	defer cli.Close()
 
-	tool.RegisterTool(ctx, srv, cli)
+	tool.RegisterTool(ctx, srv, cli, cfg)
 
 	//启动
 	if err := server.ServeStdio(srv); err != nil {
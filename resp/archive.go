@@ -0,0 +1,21 @@
+package resp
+
+type Archive struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	Digest      string `json:"digest,omitempty"`
+	Compression string `json:"compression"`
+}
+
+type ImageArchive struct {
+	Archive
+	Images []ArchivedImage `json:"images"`
+}
+
+type ArchivedImage struct {
+	Reference   string   `json:"reference,omitempty"`
+	ID          string   `json:"id"`
+	RepoTags    []string `json:"repoTags,omitempty"`
+	RepoDigests []string `json:"repoDigests,omitempty"`
+	Size        int64    `json:"size"`
+}
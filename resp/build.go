@@ -0,0 +1,17 @@
+package resp
+
+type ImageBuild struct {
+	ImageID    string      `json:"imageId,omitempty"`
+	Tags       []string    `json:"tags,omitempty"`
+	Steps      []BuildStep `json:"steps"`
+	FailedStep *BuildStep  `json:"failedStep,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+type BuildStep struct {
+	Number      int      `json:"number"`
+	Total       int      `json:"total"`
+	Instruction string   `json:"instruction"`
+	Cached      bool     `json:"cached"`
+	Output      []string `json:"output,omitempty"`
+}
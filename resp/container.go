@@ -2,24 +2,106 @@ package resp
 
 import (
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/pkg/jsonmessage"
 )
 
 type Container struct {
-	ID      string
-	Names   []string
-	Command string
-	Created int64
-	Ports   []container.Port
-	Image   string
+	ID       string
+	Names    []string
+	Command  string
+	Created  int64
+	Ports    []container.Port
+	Image    string
+	State    string
+	Status   string
+	Labels   map[string]string
+	Mounts   []container.MountPoint
+	Networks map[string]string
 }
 
 type ContainerRun struct {
-	Create  ContainerCreate
-	PullMsg []jsonmessage.JSONMessage
+	Create ContainerCreate
+	Pull   *ImagePull
 }
 
 type ContainerCreate struct {
 	ID       string   `json:"Id"`
 	Warnings []string `json:"Warnings"`
 }
+
+type ContainerRecreate struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	OldID      string   `json:"oldId"`
+	OldImage   string   `json:"oldImage"`
+	NewImage   string   `json:"newImage"`
+	Health     string   `json:"health"`
+	OldRemoved bool     `json:"oldRemoved"`
+	OldName    string   `json:"oldName,omitempty"`
+	RolledBack bool     `json:"rolledBack"`
+	Reason     string   `json:"reason,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+type ContainerClone struct {
+	ID       string              `json:"id"`
+	Name     string              `json:"name"`
+	Source   string              `json:"source"`
+	Image    string              `json:"image"`
+	Snapshot string              `json:"snapshot,omitempty"`
+	Started  bool                `json:"started"`
+	Volumes  []ClonedVolume      `json:"volumes,omitempty"`
+	Ports    map[string][]string `json:"ports,omitempty"`
+	Warnings []string            `json:"warnings,omitempty"`
+	// CleanedUp 克隆失败时已删除的半成品，Leftover 是删除失败、需要手动清理的
+	CleanedUp []string `json:"cleanedUp,omitempty"`
+	Leftover  []string `json:"leftover,omitempty"`
+}
+
+type ClonedVolume struct {
+	Destination string `json:"destination"`
+	Source      string `json:"source"`
+	Volume      string `json:"volume"`
+	Mode        string `json:"mode"`
+}
+
+type ContainerCommit struct {
+	ID        string `json:"id"`
+	Container string `json:"container"`
+	Reference string `json:"reference,omitempty"`
+	Size      int64  `json:"size"`
+	SizeHuman string `json:"sizeHuman,omitempty"`
+}
+
+type ContainerRemove struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name,omitempty"`
+	Removed          bool     `json:"removed"`
+	Stopped          bool     `json:"stopped,omitempty"`
+	AnonymousVolumes []string `json:"anonymousVolumes,omitempty"`
+	VolumesRemoved   bool     `json:"volumesRemoved,omitempty"`
+	Error            string   `json:"error,omitempty"`
+}
+
+type ContainerUpdate struct {
+	ID       string             `json:"id"`
+	Name     string             `json:"name"`
+	Before   ContainerResources `json:"before"`
+	After    ContainerResources `json:"after"`
+	Changed  []string           `json:"changed"`
+	Warnings []string           `json:"warnings,omitempty"`
+}
+
+type ContainerResources struct {
+	Memory            int64   `json:"memory"`
+	MemorySwap        int64   `json:"memorySwap"`
+	MemoryReservation int64   `json:"memoryReservation"`
+	CPUShares         int64   `json:"cpuShares"`
+	CPUs              float64 `json:"cpus"`
+	CPUPeriod         int64   `json:"cpuPeriod"`
+	CPUQuota          int64   `json:"cpuQuota"`
+	CpusetCpus        string  `json:"cpusetCpus"`
+	CpusetMems        string  `json:"cpusetMems"`
+	PidsLimit         int64   `json:"pidsLimit"`
+	BlkioWeight       uint16  `json:"blkioWeight"`
+	RestartPolicy     string  `json:"restartPolicy"`
+}
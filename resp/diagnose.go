@@ -0,0 +1,82 @@
+package resp
+
+type ContainerDiagnosis struct {
+	ID       string             `json:"id"`
+	Name     string             `json:"name"`
+	Image    string             `json:"image"`
+	State    DiagnosisState     `json:"state"`
+	Health   *DiagnosisHealth   `json:"health,omitempty"`
+	Logs     []string           `json:"logs"`
+	Events   []DiagnosisEvent   `json:"events"`
+	Usage    *DiagnosisUsage    `json:"usage,omitempty"`
+	Ports    []DiagnosisPort    `json:"ports,omitempty"`
+	Networks []DiagnosisNetwork `json:"networks,omitempty"`
+	Causes   []DiagnosisCause   `json:"causes"`
+	Errors   map[string]string  `json:"errors,omitempty"`
+}
+
+type DiagnosisState struct {
+	Status       string `json:"status"`
+	Running      bool   `json:"running"`
+	Restarting   bool   `json:"restarting"`
+	OOMKilled    bool   `json:"oomKilled"`
+	ExitCode     int    `json:"exitCode"`
+	Error        string `json:"error,omitempty"`
+	StartedAt    string `json:"startedAt,omitempty"`
+	FinishedAt   string `json:"finishedAt,omitempty"`
+	RestartCount int    `json:"restartCount"`
+}
+
+type DiagnosisHealth struct {
+	Status        string                 `json:"status"`
+	FailingStreak int                    `json:"failingStreak"`
+	Test          []string               `json:"test,omitempty"`
+	Log           []DiagnosisHealthProbe `json:"log,omitempty"`
+}
+
+type DiagnosisHealthProbe struct {
+	Start    string `json:"start"`
+	ExitCode int    `json:"exitCode"`
+	Output   string `json:"output"`
+}
+
+type DiagnosisEvent struct {
+	Time       string            `json:"time"`
+	Action     string            `json:"action"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+type DiagnosisUsage struct {
+	MemoryUsage   uint64  `json:"memoryUsage"`
+	MemoryLimit   uint64  `json:"memoryLimit"`
+	MemoryPercent float64 `json:"memoryPercent"`
+	MemoryFailcnt uint64  `json:"memoryFailcnt,omitempty"`
+	CPUPercent    float64 `json:"cpuPercent"`
+	CPULimit      float64 `json:"cpuLimit,omitempty"`
+	Throttled     uint64  `json:"throttledPeriods,omitempty"`
+	Pids          uint64  `json:"pids"`
+	PidsLimit     uint64  `json:"pidsLimit,omitempty"`
+}
+
+type DiagnosisPort struct {
+	ContainerPort string `json:"containerPort"`
+	HostIP        string `json:"hostIp,omitempty"`
+	HostPort      string `json:"hostPort,omitempty"`
+	// Probed 为 false 时 Reachable 没有意义，NotProbed 说明未探测的原因
+	Probed    bool   `json:"probed"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+	NotProbed string `json:"notProbed,omitempty"`
+}
+
+type DiagnosisNetwork struct {
+	Name      string `json:"name"`
+	IPAddress string `json:"ipAddress,omitempty"`
+	Gateway   string `json:"gateway,omitempty"`
+}
+
+type DiagnosisCause struct {
+	Code     string   `json:"code"`
+	Summary  string   `json:"summary"`
+	Evidence []string `json:"evidence,omitempty"`
+}
@@ -0,0 +1,38 @@
+package resp
+
+import "time"
+
+type ContainerFile struct {
+	Container  string               `json:"container"`
+	Path       string               `json:"path"`
+	Type       string               `json:"type"`
+	Size       int64                `json:"size"`
+	Mode       string               `json:"mode"`
+	Mtime      time.Time            `json:"mtime"`
+	LinkTarget string               `json:"linkTarget,omitempty"`
+	Encoding   string               `json:"encoding,omitempty"`
+	Content    string               `json:"content,omitempty"`
+	Entries    []ContainerFileEntry `json:"entries,omitempty"`
+	Truncated  bool                 `json:"truncated,omitempty"`
+}
+
+type ContainerFileEntry struct {
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Size       int64     `json:"size"`
+	Mode       string    `json:"mode"`
+	Mtime      time.Time `json:"mtime"`
+	LinkTarget string    `json:"linkTarget,omitempty"`
+}
+
+type ContainerFileWrite struct {
+	Container string `json:"container"`
+	Path      string `json:"path"`
+	// LinkTarget path 是符号链接时的指向，内容写入链接的最终目标
+	LinkTarget string `json:"linkTarget,omitempty"`
+	Size       int64  `json:"size"`
+	Mode       string `json:"mode"`
+	UID        int    `json:"uid"`
+	GID        int    `json:"gid"`
+	Created    bool   `json:"created"`
+}
@@ -0,0 +1,40 @@
+package resp
+
+type ImageHistory struct {
+	Image      string           `json:"image"`
+	ID         string           `json:"id"`
+	TotalSize  int64            `json:"totalSize"`
+	TotalHuman string           `json:"totalHuman"`
+	Layers     []HistoryLayer   `json:"layers"`
+	Largest    []HistoryLayer   `json:"largest"`
+	Breakdown  HistoryBreakdown `json:"breakdown"`
+}
+
+type HistoryLayer struct {
+	Index     int      `json:"index"`
+	ID        string   `json:"id,omitempty"`
+	CreatedBy string   `json:"createdBy"`
+	Created   string   `json:"created"`
+	Age       string   `json:"age"`
+	Size      int64    `json:"size"`
+	SizeHuman string   `json:"sizeHuman"`
+	Percent   float64  `json:"percent"`
+	Empty     bool     `json:"empty"`
+	Base      bool     `json:"base"`
+	Tags      []string `json:"tags,omitempty"`
+	Comment   string   `json:"comment,omitempty"`
+}
+
+type HistoryBreakdown struct {
+	BaseImage string       `json:"baseImage,omitempty"`
+	Method    string       `json:"method"`
+	Base      HistoryGroup `json:"base"`
+	Added     HistoryGroup `json:"added"`
+}
+
+type HistoryGroup struct {
+	Layers    int     `json:"layers"`
+	Size      int64   `json:"size"`
+	SizeHuman string  `json:"sizeHuman"`
+	Percent   float64 `json:"percent"`
+}
@@ -1,9 +1,72 @@
 package resp
 
 type Image struct {
-	Repository []string
-	Tag        string
-	ImageID    string
-	Created    int64
-	Size       int64
+	Reference   string
+	Registry    string
+	Repository  string
+	Tag         string
+	RepoDigests []string
+	// Dangling 没有标签的顶层镜像；Intermediate 没有标签、作为其他镜像父镜像的中间层，不算悬空
+	Dangling     bool
+	Intermediate bool
+	ImageID      string
+	Created      int64
+	Size         int64
+	Containers   int64
+	Labels       map[string]string
+}
+
+type ImagePush struct {
+	Reference string        `json:"reference"`
+	Tag       string        `json:"tag,omitempty"`
+	Digest    string        `json:"digest,omitempty"`
+	Size      int64         `json:"size,omitempty"`
+	Layers    []LayerStatus `json:"layers,omitempty"`
+}
+
+type ImagePull struct {
+	Reference  string        `json:"reference"`
+	Platform   string        `json:"platform,omitempty"`
+	Digest     string        `json:"digest,omitempty"`
+	Status     string        `json:"status,omitempty"`
+	Tags       []PulledTag   `json:"tags,omitempty"`
+	Downloaded int64         `json:"downloaded"`
+	LayerCount int           `json:"layerCount"`
+	LayersDone int           `json:"layersDone"`
+	Layers     []LayerStatus `json:"layers,omitempty"`
+}
+
+type PulledTag struct {
+	Tag    string `json:"tag"`
+	Digest string `json:"digest,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+type LayerStatus struct {
+	ID      string `json:"id"`
+	Status  string `json:"status"`
+	Current int64  `json:"current,omitempty"`
+	Total   int64  `json:"total,omitempty"`
+}
+
+type ImageTag struct {
+	Source  string   `json:"source"`
+	ImageID string   `json:"imageId"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+type ImagePrune struct {
+	Deleted             []string `json:"deleted"`
+	Untagged            []string `json:"untagged"`
+	SpaceReclaimed      uint64   `json:"spaceReclaimed"`
+	SpaceReclaimedHuman string   `json:"spaceReclaimedHuman"`
+}
+
+type ImageRemove struct {
+	Image    string   `json:"image"`
+	Removed  bool     `json:"removed"`
+	Untagged []string `json:"untagged,omitempty"`
+	Deleted  []string `json:"deleted,omitempty"`
+	Error    string   `json:"error,omitempty"`
 }
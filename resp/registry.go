@@ -0,0 +1,28 @@
+package resp
+
+type ImageSearchResult struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Stars       int    `json:"stars"`
+	Official    bool   `json:"official"`
+}
+
+type RemoteTags struct {
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Total      int    `json:"total"`
+	// Truncated 仓库的标签页数超过上限，Tags 和 Total 只包含已读取的部分
+	Truncated bool             `json:"truncated,omitempty"`
+	Tags      []string         `json:"tags"`
+	Current   *RemoteManifest  `json:"current,omitempty"`
+	Manifests []RemoteManifest `json:"manifests,omitempty"`
+}
+
+type RemoteManifest struct {
+	Tag       string   `json:"tag"`
+	Digest    string   `json:"digest,omitempty"`
+	MediaType string   `json:"mediaType,omitempty"`
+	Size      int64    `json:"size,omitempty"`
+	Platforms []string `json:"platforms,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
@@ -8,3 +8,12 @@ type System struct {
 	NodeState        string
 	ControlAvailable bool
 }
+
+type Event struct {
+	Time       string            `json:"time"`
+	Type       string            `json:"type"`
+	Action     string            `json:"action"`
+	ID         string            `json:"id"`
+	Name       string            `json:"name,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
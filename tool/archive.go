@@ -0,0 +1,167 @@
+package tool
+
+import (
+	"context"
+	"docker-mcp/api"
+	"docker-mcp/cmd/logs"
+	"encoding/json"
+	"errors"
+	"github.com/docker/docker/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RegisterArchiveTool 注册在主机上读写归档文件的工具，文件只能位于 archiveDir 内
+func RegisterArchiveTool(ctx context.Context, srv *server.MCPServer, cli *client.Client, archiveDir string) {
+	RegisterContainerExportTool(ctx, srv, cli, archiveDir)
+	RegisterImageSaveTool(ctx, srv, cli, archiveDir)
+	RegisterImageLoadTool(ctx, srv, cli, archiveDir)
+}
+
+func RegisterContainerExportTool(ctx context.Context, srv *server.MCPServer, cli *client.Client, archiveDir string) {
+	tool := mcp.NewTool("mcp_docker_container_export",
+		mcp.WithDescription("Export a container's filesystem as a tar archive on the MCP server host - equivalent to 'docker export -o <file> <container>' - For forensics. The file is written inside the configured archive directory, optionally gzip-compressed, and the tool returns its path, size and sha256 digest"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+		mcp.WithString("path",
+			mcp.Description("Output file path relative to the archive directory. Defaults to <name>-<time>.tar[.gz]")),
+		mcp.WithString("compression",
+			mcp.DefaultString(api.CompressionNone),
+			mcp.Enum(api.CompressionNone, api.CompressionGzip),
+			mcp.Description("Compression of the written file: none or gzip")),
+		mcp.WithBoolean("hash",
+			mcp.DefaultBool(true),
+			mcp.Description("Compute the sha256 digest of the written file")),
+		mcp.WithBoolean("overwrite",
+			mcp.DefaultBool(false),
+			mcp.Description("Overwrite the file if it already exists")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		opts := api.ArchiveOptions{Compression: api.CompressionNone, Hash: true}
+		if val, ok := request.Params.Arguments["path"].(string); ok {
+			opts.Path = val
+		}
+		if val, ok := request.Params.Arguments["compression"].(string); ok && val != "" {
+			opts.Compression = val
+		}
+		if val, ok := request.Params.Arguments["hash"].(bool); ok {
+			opts.Hash = val
+		}
+		if val, ok := request.Params.Arguments["overwrite"].(bool); ok {
+			opts.Overwrite = val
+		}
+		logs.InfoObjects("mcp_docker_container_export called", "id", id, "options", opts)
+		archive, err := api.ExportContainer(ctx, cli, id, archiveDir, opts)
+		if err != nil {
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   archive,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterImageSaveTool(ctx context.Context, srv *server.MCPServer, cli *client.Client, archiveDir string) {
+	tool := mcp.NewTool("mcp_docker_image_save",
+		mcp.WithDescription("Save one or more images to a tar archive on the MCP server host - equivalent to 'docker save -o <file> <image>...' - For air-gapped transfers. The file is written inside the configured archive directory, optionally gzip-compressed, and the result lists the archive digest plus the ID and digests of every image"),
+		mcp.WithArray("images",
+			mcp.Required(),
+			mcp.Items(map[string]any{"type": "string"}),
+			mcp.Description("Image references or IDs to include, e.g. [\"nginx:1.27\", \"redis:7\"]")),
+		mcp.WithString("path",
+			mcp.Description("Output file path relative to the archive directory. Defaults to <image>-<time>.tar[.gz]")),
+		mcp.WithString("compression",
+			mcp.DefaultString(api.CompressionNone),
+			mcp.Enum(api.CompressionNone, api.CompressionGzip),
+			mcp.Description("Compression of the written file: none or gzip")),
+		mcp.WithBoolean("overwrite",
+			mcp.DefaultBool(false),
+			mcp.Description("Overwrite the file if it already exists")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var images []string
+		if val, ok := request.Params.Arguments["images"].([]any); ok {
+			for _, img := range val {
+				if str, ok := img.(string); ok && str != "" {
+					images = append(images, str)
+				}
+			}
+		}
+		if len(images) == 0 {
+			return nil, errors.New("images parameter is required and must be a non-empty array")
+		}
+		opts := api.ArchiveOptions{Compression: api.CompressionNone, Hash: true}
+		if val, ok := request.Params.Arguments["path"].(string); ok {
+			opts.Path = val
+		}
+		if val, ok := request.Params.Arguments["compression"].(string); ok && val != "" {
+			opts.Compression = val
+		}
+		if val, ok := request.Params.Arguments["overwrite"].(bool); ok {
+			opts.Overwrite = val
+		}
+		logs.InfoObjects("mcp_docker_image_save called", "images", images, "options", opts)
+		archive, err := api.SaveImages(ctx, cli, images, archiveDir, opts)
+		if err != nil {
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   archive,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterImageLoadTool(ctx context.Context, srv *server.MCPServer, cli *client.Client, archiveDir string) {
+	tool := mcp.NewTool("mcp_docker_image_load",
+		mcp.WithDescription("Load images from a tar archive on the MCP server host - equivalent to 'docker load -i <file>' - Reads a plain or gzip-compressed 'docker save' archive from the configured archive directory and returns the archive digest and the loaded images"),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Archive path relative to the archive directory")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, ok := request.Params.Arguments["path"].(string)
+		if !ok || path == "" {
+			return nil, errors.New("path parameter is required and must be a string")
+		}
+		logs.InfoWithFields("mcp_docker_image_load called", map[string]interface{}{"path": path})
+		archive, err := api.LoadImages(ctx, cli, archiveDir, path)
+		if err != nil {
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   archive,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
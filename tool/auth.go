@@ -2,6 +2,7 @@ package tool
 
 import (
 	"context"
+	"docker-mcp/api"
 	"encoding/json"
 	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/client"
@@ -16,7 +17,7 @@ func RegisterAuthTool(ctx context.Context, srv *server.MCPServer, cli *client.Cl
 
 func RegisterRegistryTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
 	tool := mcp.NewTool("mcp_docker_auth_registry",
-		mcp.WithDescription("Login to Docker Registry,Equivalent to a command: docker login "),
+		mcp.WithDescription("Login to Docker Registry,Equivalent to a command: docker login - The credentials are kept by the server and used automatically by later pull, push, run and build operations for the same registry. Registries without a login fall back to ~/.docker/config.json and docker-credential-* helpers"),
 		mcp.WithString("username",
 			mcp.Required(),
 			mcp.Description("Docker registry username")),
@@ -32,17 +33,25 @@ func RegisterRegistryTool(ctx context.Context, srv *server.MCPServer, cli *clien
 		password := request.Params.Arguments["password"].(string)
 		serverAddress := request.Params.Arguments["serverAddress"].(string)
 
-		loginResp, err := cli.RegistryLogin(ctx, registry.AuthConfig{
+		auth := registry.AuthConfig{
 			Username:      username,
 			Password:      password,
 			ServerAddress: serverAddress,
-		})
+		}
+		loginResp, err := cli.RegistryLogin(ctx, auth)
 		if err != nil {
 			return nil, err
 		}
+		// 仓库返回了令牌时保存令牌而不是密码
+		if loginResp.IdentityToken != "" {
+			auth.Password = ""
+			auth.IdentityToken = loginResp.IdentityToken
+		}
+		api.Credentials.Set(auth)
 		result, _ := json.Marshal(map[string]interface{}{
 			"status":       "success",
 			"login_status": loginResp.Status,
+			"registry":     api.RegistryHost(serverAddress),
 		})
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -7,21 +7,33 @@ import (
 	"docker-mcp/resp"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"time"
 )
 
 func RegisterContainerTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
 	RegisterContainerListTool(ctx, srv, cli)
 	RegisterContainerRunTool(ctx, srv, cli)
+	RegisterContainerCreateTool(ctx, srv, cli)
+	RegisterContainerRunCommandTool(ctx, srv, cli)
+	RegisterContainerRunlikeTool(ctx, srv, cli)
+	RegisterContainerRecreateTool(ctx, srv, cli)
+	RegisterContainerCloneTool(ctx, srv, cli)
+	RegisterContainerCommitTool(ctx, srv, cli)
+	RegisterContainerUpdateTool(ctx, srv, cli)
 	RegisterContainerStartTool(ctx, srv, cli)
 	RegisterContainerStopTool(ctx, srv, cli)
 	RegisterContainerRestartTool(ctx, srv, cli)
 	RegisterContainerRemoveTool(ctx, srv, cli)
 	RegisterContainerInspectTool(ctx, srv, cli)
 	RegisterContainerLogsTool(ctx, srv, cli)
+	RegisterContainerDiagnoseTool(ctx, srv, cli)
+	RegisterContainerFileTool(ctx, srv, cli)
+	RegisterContainerLifecycleTool(ctx, srv, cli)
 }
 
 func RegisterContainerLogsTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
@@ -93,12 +105,14 @@ func RegisterContainerRestartTool(ctx context.Context, srv *server.MCPServer, cl
 		mcp.WithDescription("Restart a container - equivalent to 'docker restart <container-id>' - Gracefully stops and starts a container"),
 		mcp.WithString("id",
 			mcp.Description("Container ID or container name")),
+		stopTimeoutOption(),
+		stopSignalOption(),
 	)
 	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		id := request.Params.Arguments["id"].(string)
-		timeout := 5
-		logs.InfoWithFields("mcp_docker_container_restart called", map[string]interface{}{"id": id, "timeout": timeout})
-		if err := cli.ContainerRestart(ctx, id, container.StopOptions{Timeout: &timeout}); err != nil {
+		stopOptions := stopOptionsFromRequest(request)
+		logs.InfoWithFields("mcp_docker_container_restart called", map[string]interface{}{"id": id, "timeout": *stopOptions.Timeout, "signal": stopOptions.Signal})
+		if err := cli.ContainerRestart(ctx, id, stopOptions); err != nil {
 			logs.ErrorWithFields("ContainerRestart failed", map[string]interface{}{"id": id, "error": err})
 			return nil, err
 		}
@@ -119,14 +133,15 @@ func RegisterContainerRestartTool(ctx context.Context, srv *server.MCPServer, cl
 
 func RegisterContainerStopTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
 	tool := mcp.NewTool("mcp_docker_container_stop",
-		mcp.WithDescription("Stop a running container - equivalent to 'docker stop <container-id>' - Sends SIGTERM signal to the main process"),
+		mcp.WithDescription("Stop a running container - equivalent to 'docker stop <container-id>' - Sends SIGTERM (or the given signal) to the main process and kills it after the timeout"),
 		mcp.WithString("id",
 			mcp.Description("Container ID or container name")),
+		stopTimeoutOption(),
+		stopSignalOption(),
 	)
 	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		id := request.Params.Arguments["id"].(string)
-		time := 5
-		if err := cli.ContainerStop(ctx, id, container.StopOptions{Timeout: &time}); err != nil {
+		if err := cli.ContainerStop(ctx, id, stopOptionsFromRequest(request)); err != nil {
 			return nil, err
 		}
 		result, _ := json.Marshal(map[string]string{
@@ -170,27 +185,72 @@ func RegisterContainerStartTool(ctx context.Context, srv *server.MCPServer, cli
 
 func RegisterContainerRemoveTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
 	tool := mcp.NewTool("mcp_docker_container_remove",
-		mcp.WithDescription("Remove a container - equivalent to 'docker rm <container-id>' - Automatically stops and removes the specified container"),
+		mcp.WithDescription("Remove one or more containers - equivalent to 'docker rm [-f] <container-id>...' - Select containers by ID/name and/or label. Running containers are refused, gracefully stopped first, or force-killed depending on mode. Reports per-container results and the anonymous volumes involved"),
 		mcp.WithString("id",
 			mcp.Description("Container ID or container name")),
+		mcp.WithArray("ids",
+			mcp.Items(map[string]any{"type": "string"}),
+			mcp.Description("Multiple container IDs or names")),
+		mcp.WithArray("labels",
+			mcp.Items(map[string]any{"type": "string"}),
+			mcp.Description("Remove all containers matching every label selector, e.g. [\"app=web\", \"env\"]")),
+		mcp.WithString("mode",
+			mcp.DefaultString(api.RemoveStop),
+			mcp.Enum(api.RemoveRefuse, api.RemoveStop, api.RemoveForce),
+			mcp.Description("refuse: fail for running containers; stop: gracefully stop then remove (default); force: kill and remove")),
+		mcp.WithNumber("timeout",
+			mcp.Description("Seconds to wait for a graceful stop in stop mode. Defaults to the container's stop timeout")),
 		mcp.WithBoolean("removeVolumes",
-			mcp.Description("Whether to remove volumes associated with the container")),
+			mcp.DefaultBool(false),
+			mcp.Description("Also remove anonymous volumes of the container. Named volumes are never removed")),
 	)
 	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		id := request.Params.Arguments["id"].(string)
-		//先关闭后删除
-		if err := cli.ContainerStop(ctx, id, container.StopOptions{}); err != nil {
-			return nil, err
+		var ids, labels []string
+		if val, ok := request.Params.Arguments["id"].(string); ok && val != "" {
+			ids = append(ids, val)
+		}
+		if val, ok := request.Params.Arguments["ids"].([]any); ok {
+			for _, id := range val {
+				if str, ok := id.(string); ok && str != "" {
+					ids = append(ids, str)
+				}
+			}
+		}
+		if val, ok := request.Params.Arguments["labels"].([]any); ok {
+			for _, label := range val {
+				if str, ok := label.(string); ok && str != "" {
+					labels = append(labels, str)
+				}
+			}
+		}
+		if len(ids) == 0 && len(labels) == 0 {
+			return nil, errors.New("id, ids or labels parameter is required")
 		}
-		removeVolumes := request.Params.Arguments["removeVolumes"].(bool)
-		if err := cli.ContainerRemove(ctx, id, container.RemoveOptions{
-			Force:         true,
-			RemoveVolumes: removeVolumes,
-		}); err != nil {
+		opts := api.RemoveOptions{Mode: api.RemoveStop}
+		if val, ok := request.Params.Arguments["mode"].(string); ok && val != "" {
+			opts.Mode = val
+		}
+		if val, ok := request.Params.Arguments["timeout"].(float64); ok {
+			timeout := int(val)
+			opts.StopTimeout = &timeout
+		}
+		if val, ok := request.Params.Arguments["removeVolumes"].(bool); ok {
+			opts.RemoveVolumes = val
+		}
+		logs.InfoObjects("mcp_docker_container_remove called", "ids", ids, "labels", labels, "options", opts)
+		removed, err := api.RemoveContainers(ctx, cli, ids, labels, opts)
+		if err != nil {
 			return nil, err
 		}
-		result, _ := json.Marshal(map[string]string{
-			"status": "success",
+		succeeded := 0
+		for _, r := range removed {
+			if r.Removed {
+				succeeded++
+			}
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": batchStatus(succeeded, len(removed)),
+			"data":   removed,
 		})
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -203,64 +263,143 @@ func RegisterContainerRemoveTool(ctx context.Context, srv *server.MCPServer, cli
 	})
 }
 
-func RegisterContainerRunTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
-	tool := mcp.NewTool("mcp_docker_container_run",
-		mcp.WithDescription("Run a Docker image - equivalent to 'docker run <image>' - Pulls the image (if not present locally), then creates and starts a container"),
+// containerSpecOptions 是 run 和 create 共用的参数定义，与 api.ContainerSpec 的 JSON 字段一一对应
+func containerSpecOptions() []mcp.ToolOption {
+	stringArray := map[string]any{"type": "string"}
+	stringMap := map[string]any{"type": "string"}
+	return []mcp.ToolOption{
 		mcp.WithString("image",
 			mcp.Required(),
 			mcp.Description("Image name in format: [registry/][username/]name[:tag], e.g., redis or docker.io/library/redis:latest")),
-		mcp.WithString("env",
-			mcp.DefaultString(""),
-			mcp.Description("Environment variables in format: VAR1=value1,VAR2=value2. For example: MYSQL_ROOT_PASSWORD=password,MYSQL_DATABASE=mydb")),
-		mcp.WithString("containerName",
-			mcp.DefaultString(""),
+		mcp.WithString("name",
 			mcp.Description("Assign a name to the container. If not specified, Docker will generate a random name")),
-		mcp.WithString("ports",
-			mcp.DefaultString(""),
-			mcp.Description("Port mappings in format: [hostIP:]hostPort:containerPort[/protocol]. Multiple mappings separated by commas. Examples: 8080:80/tcp,127.0.0.1:5432:5432,3306:3306")),
-		mcp.WithString("volumes",
-			mcp.DefaultString(""),
-			mcp.Description("Volume mappings in format: hostPath:containerPath[:mode]. Multiple volumes separated by commas. Examples: /data:/var/lib/mysql,/config:/etc/mysql/conf.d:ro")),
-	)
+		mcp.WithArray("cmd",
+			mcp.Items(stringArray),
+			mcp.Description("Command and arguments overriding the image CMD, e.g. [\"redis-server\", \"--appendonly\", \"yes\"]")),
+		mcp.WithArray("entrypoint",
+			mcp.Items(stringArray),
+			mcp.Description("Entrypoint overriding the image ENTRYPOINT, e.g. [\"/bin/sh\", \"-c\"]")),
+		mcp.WithArray("env",
+			mcp.Items(stringArray),
+			mcp.Description("Environment variables in KEY=value form, e.g. [\"MYSQL_ROOT_PASSWORD=password\", \"MYSQL_DATABASE=mydb\"]")),
+		mcp.WithString("workingDir",
+			mcp.Description("Working directory inside the container")),
+		mcp.WithString("user",
+			mcp.Description("User to run as, in format user[:group] or uid[:gid]")),
+		mcp.WithString("hostname",
+			mcp.Description("Container hostname")),
+		mcp.WithObject("labels",
+			mcp.AdditionalProperties(stringMap),
+			mcp.Description("Container labels, e.g. {\"app\": \"web\", \"env\": \"prod\"}")),
+		mcp.WithArray("ports",
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"hostIp":        map[string]any{"type": "string", "description": "Host IP to bind, default all interfaces"},
+					"hostPort":      map[string]any{"type": "string", "description": "Host port; 0 for a random free port, omit together with hostIp to only expose the container port"},
+					"containerPort": map[string]any{"type": "string", "description": "Container port, optionally with protocol, e.g. 80 or 53/udp"},
+					"protocol":      map[string]any{"type": "string", "enum": []string{"tcp", "udp", "sctp"}},
+				},
+				"required": []string{"containerPort"},
+			}),
+			mcp.Description("Port mappings, e.g. [{\"hostPort\": \"8080\", \"containerPort\": \"80\"}]")),
+		mcp.WithArray("volumes",
+			mcp.Items(stringArray),
+			mcp.Description("Volume mounts in format source:target[:mode], or a bare container path for an anonymous volume, e.g. [\"/data:/var/lib/mysql\", \"conf:/etc/mysql/conf.d:ro\"]")),
+		mcp.WithObject("tmpfs",
+			mcp.AdditionalProperties(stringMap),
+			mcp.Description("tmpfs mounts keyed by container path with mount options as value, e.g. {\"/run\": \"rw,size=64m\"}")),
+		mcp.WithString("restartPolicy",
+			mcp.Description("Restart policy: no, always, unless-stopped or on-failure[:max-retries]")),
+		mcp.WithString("memory",
+			mcp.Description("Memory limit, e.g. 512m or 2g")),
+		mcp.WithString("memorySwap",
+			mcp.Description("Total memory plus swap limit, e.g. 1g; -1 for unlimited swap")),
+		mcp.WithNumber("cpus",
+			mcp.Description("Number of CPUs, e.g. 1.5")),
+		mcp.WithNumber("cpuShares",
+			mcp.Description("CPU shares (relative weight)")),
+		mcp.WithNumber("pidsLimit",
+			mcp.Description("Maximum number of processes, -1 for unlimited")),
+		mcp.WithArray("networks",
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name":        map[string]any{"type": "string"},
+					"aliases":     map[string]any{"type": "array", "items": stringArray},
+					"ipv4Address": map[string]any{"type": "string"},
+				},
+				"required": []string{"name"},
+			}),
+			mcp.Description("Networks to attach; the first one becomes the network mode, e.g. [{\"name\": \"backend\", \"aliases\": [\"db\"]}]")),
+		mcp.WithArray("extraHosts",
+			mcp.Items(stringArray),
+			mcp.Description("Additional /etc/hosts entries in host:ip form, e.g. [\"db.local:10.0.0.5\"]")),
+		mcp.WithArray("capAdd",
+			mcp.Items(stringArray),
+			mcp.Description("Linux capabilities to add, e.g. [\"NET_ADMIN\"]")),
+		mcp.WithArray("capDrop",
+			mcp.Items(stringArray),
+			mcp.Description("Linux capabilities to drop, e.g. [\"ALL\"]")),
+		mcp.WithBoolean("privileged",
+			mcp.Description("Give extended privileges to the container")),
+		mcp.WithObject("healthcheck",
+			mcp.Properties(map[string]any{
+				"test":        map[string]any{"type": "array", "items": stringArray, "description": "Check command; a single string runs in a shell, e.g. [\"curl -f http://localhost/\"]"},
+				"interval":    map[string]any{"type": "string", "description": "Go duration, e.g. 30s"},
+				"timeout":     map[string]any{"type": "string", "description": "Go duration, e.g. 5s"},
+				"startPeriod": map[string]any{"type": "string", "description": "Go duration, e.g. 10s"},
+				"retries":     map[string]any{"type": "number"},
+			}),
+			mcp.Description("Container healthcheck overriding the image HEALTHCHECK")),
+		mcp.WithBoolean("autoRemove",
+			mcp.Description("Automatically remove the container when it exits, like --rm")),
+		mcp.WithBoolean("tty",
+			mcp.Description("Allocate a pseudo-TTY, like -t")),
+		mcp.WithBoolean("interactive",
+			mcp.Description("Keep STDIN open, like -i")),
+	}
+}
+
+func RegisterContainerRunTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	opts := []mcp.ToolOption{
+		mcp.WithDescription("Run a Docker image - equivalent to 'docker run -d <image>' - Pulls the image, then creates and starts a container with the full configuration: command, entrypoint, env, ports, volumes, restart policy, resource limits, networks, healthcheck and more"),
+		mcp.WithString("pull",
+			mcp.DefaultString(api.PullAlways),
+			mcp.Enum(api.PullAlways, api.PullMissing, api.PullNever),
+			mcp.Description("When to pull the image: always (default), missing or never")),
+	}
+	tool := mcp.NewTool("mcp_docker_container_run", append(opts, containerSpecOptions()...)...)
 	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		images, ok := request.Params.Arguments["image"].(string)
-		if !ok || images == "" {
-			return nil, errors.New("image parameter is required and must be a string")
-		}
-		env, containerName, ports, volumes := "", "", "", ""
-		if val, ok := request.Params.Arguments["env"]; ok {
-			env = val.(string)
-		}
-		if val, ok := request.Params.Arguments["containerName"]; ok {
-			containerName = val.(string)
+		var spec api.ContainerSpec
+		if err := bindArguments(request, &spec); err != nil {
+			return nil, err
 		}
-		if val, ok := request.Params.Arguments["ports"]; ok {
-			ports = val.(string)
+		if spec.Image == "" {
+			return nil, errors.New("image parameter is required and must be a string")
 		}
-		if val, ok := request.Params.Arguments["volumes"]; ok {
-			volumes = val.(string)
+		pull := api.PullAlways
+		if val, ok := request.Params.Arguments["pull"].(string); ok && val != "" {
+			pull = val
 		}
-		logs.Info("mcp_docker_container_run tool being visited: %s %s %s %s", env, containerName, ports, volumes)
+		logs.InfoObjects("mcp_docker_container_run called", "spec", spec, "pull", pull)
 		//拉取镜像
-		pullMsg, err := api.PullImage(ctx, cli, images)
-		logs.Info("mcp_docker_container_run tool image pull.....")
+		pulled, err := api.EnsureImage(ctx, cli, spec.Image, pull)
 		if err != nil {
-			logs.Error("mcp_docker_container_run tool image pull fail:", err.Error())
+			logs.Error("mcp_docker_container_run tool image pull fail: %s", err.Error())
 			return nil, err
 		}
-		logs.Info("mcp_docker_container_run tool container create.....")
-		create, err := api.ContainerCreate(ctx, cli, images, env, containerName, ports, volumes)
+		create, err := api.ContainerCreate(ctx, cli, &spec)
 		if err != nil {
-			logs.Error("mcp_docker_container_run tool container create fail:", err.Error())
+			logs.Error("mcp_docker_container_run tool container create fail: %s", err.Error())
 			return nil, err
 		}
-		logs.Info("mcp_docker_container_run tool container start.....")
 		if err := api.ContainerStart(ctx, cli, create.ID); err != nil {
-			logs.Error("mcp_docker_container_run tool container start fail:", err.Error())
+			logs.Error("mcp_docker_container_run tool container start fail: %s", err.Error())
 			return nil, err
 		}
 		containers := resp.ContainerRun{
-			PullMsg: pullMsg,
+			Pull: pulled,
 			Create: resp.ContainerCreate{
 				ID:       create.ID,
 				Warnings: create.Warnings,
@@ -279,30 +418,577 @@ func RegisterContainerRunTool(ctx context.Context, srv *server.MCPServer, cli *c
 	})
 }
 
-func RegisterContainerListTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
-	tool := mcp.NewTool("mcp_docker_container_list",
-		mcp.WithDescription("List all containers - equivalent to 'docker ps -a' - Shows all containers (running and stopped) in the system"),
+func RegisterContainerCreateTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	opts := []mcp.ToolOption{
+		mcp.WithDescription("Create a container without starting it - equivalent to 'docker create <image>' - Accepts the same configuration as mcp_docker_container_run; start it later with mcp_docker_container_start"),
+		mcp.WithString("pull",
+			mcp.DefaultString(api.PullMissing),
+			mcp.Enum(api.PullAlways, api.PullMissing, api.PullNever),
+			mcp.Description("When to pull the image: always, missing (default) or never")),
+	}
+	tool := mcp.NewTool("mcp_docker_container_create", append(opts, containerSpecOptions()...)...)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var spec api.ContainerSpec
+		if err := bindArguments(request, &spec); err != nil {
+			return nil, err
+		}
+		if spec.Image == "" {
+			return nil, errors.New("image parameter is required and must be a string")
+		}
+		pull := api.PullMissing
+		if val, ok := request.Params.Arguments["pull"].(string); ok && val != "" {
+			pull = val
+		}
+		logs.InfoObjects("mcp_docker_container_create called", "spec", spec, "pull", pull)
+		pulled, err := api.EnsureImage(ctx, cli, spec.Image, pull)
+		if err != nil {
+			logs.Error("mcp_docker_container_create tool image pull fail: %s", err.Error())
+			return nil, err
+		}
+		create, err := api.ContainerCreate(ctx, cli, &spec)
+		if err != nil {
+			logs.Error("mcp_docker_container_create tool container create fail: %s", err.Error())
+			return nil, err
+		}
+		result, _ := json.Marshal(resp.ContainerRun{
+			Pull: pulled,
+			Create: resp.ContainerCreate{
+				ID:       create.ID,
+				Warnings: create.Warnings,
+			},
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterContainerRunCommandTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_run_command",
+		mcp.WithDescription("Run a container from a pasted 'docker run' command line - e.g. 'docker run -d --name web -p 80:80 -e A=b --restart always nginx' - Parses quoting, short and long flags and repeated flags into the same spec as mcp_docker_container_run, then creates and starts the container"),
+		mcp.WithString("command",
+			mcp.Required(),
+			mcp.Description("The full docker run command line; the leading 'docker run' is optional")),
+		mcp.WithBoolean("dryRun",
+			mcp.DefaultBool(false),
+			mcp.Description("Only parse the command line and return the resulting spec without creating anything")),
 	)
 	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		list, err := cli.ContainerList(ctx, container.ListOptions{
-			All: true,
+		commandLine, ok := request.Params.Arguments["command"].(string)
+		if !ok || commandLine == "" {
+			return nil, errors.New("command parameter is required and must be a string")
+		}
+		dryRun := false
+		if val, ok := request.Params.Arguments["dryRun"].(bool); ok {
+			dryRun = val
+		}
+		logs.InfoWithFields("mcp_docker_container_run_command called", map[string]interface{}{"command": commandLine, "dryRun": dryRun})
+		runCmd, err := api.ParseRunCommand(commandLine)
+		if err != nil {
+			logs.ErrorWithFields("ParseRunCommand failed", map[string]interface{}{"command": commandLine, "error": err})
+			return nil, err
+		}
+		// 提前校验，dryRun 时也能发现参数错误
+		if _, _, _, err := runCmd.Spec.Build(); err != nil {
+			return nil, err
+		}
+		if dryRun {
+			result, _ := json.Marshal(map[string]interface{}{
+				"status": "dry-run",
+				"data":   runCmd,
+			})
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{
+						Text: string(result),
+						Type: "text",
+					},
+				},
+			}, nil
+		}
+
+		pulled, err := api.EnsureImage(ctx, cli, runCmd.Spec.Image, runCmd.Pull)
+		if err != nil {
+			logs.Error("mcp_docker_container_run_command tool image pull fail: %s", err.Error())
+			return nil, err
+		}
+		create, err := api.ContainerCreate(ctx, cli, &runCmd.Spec)
+		if err != nil {
+			logs.Error("mcp_docker_container_run_command tool container create fail: %s", err.Error())
+			return nil, err
+		}
+		if err := api.ContainerStart(ctx, cli, create.ID); err != nil {
+			logs.Error("mcp_docker_container_run_command tool container start fail: %s", err.Error())
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"spec":   runCmd,
+			"data": resp.ContainerRun{
+				Pull: pulled,
+				Create: resp.ContainerCreate{
+					ID:       create.ID,
+					Warnings: create.Warnings,
+				},
+			},
 		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterContainerRunlikeTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_runlike",
+		mcp.WithDescription("Generate the 'docker run' command that recreates an existing container - Reconstructs ports, binds, env (minus image defaults), labels, restart policy, networks, resources and command from 'docker inspect'"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+		mcp.WithBoolean("redact",
+			mcp.DefaultBool(false),
+			mcp.Description("Replace values of env vars that look sensitive (names containing PASS, PWD, SECRET, TOKEN, KEY, AUTH, CERT...) with <redacted>")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		redact := false
+		if val, ok := request.Params.Arguments["redact"].(bool); ok {
+			redact = val
+		}
+		logs.InfoWithFields("mcp_docker_container_runlike called", map[string]interface{}{"id": id, "redact": redact})
+		spec, _, err := api.InspectSpec(ctx, cli, id)
 		if err != nil {
 			return nil, err
 		}
-		containers := make([]resp.Container, 0, len(list))
-		for _, ctr := range list {
+		redacted := make([]string, 0)
+		if redact {
+			redacted = api.RedactEnv(spec)
+		}
+		args := api.RunArgs(spec)
+		result, _ := json.Marshal(map[string]interface{}{
+			"command":  api.JoinCommandLine(args),
+			"args":     args,
+			"spec":     spec,
+			"redacted": redacted,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
 
-			containers = append(containers, resp.Container{
-				ID:      ctr.ID,
-				Names:   ctr.Names,
-				Command: ctr.Command,
-				Created: ctr.Created,
-				Ports:   ctr.Ports,
-				Image:   ctr.Image,
-			})
+func RegisterContainerRecreateTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_recreate",
+		mcp.WithDescription("Recreate or upgrade a container onto a new image while keeping its configuration - like 'docker compose up' for a single container - Pulls the image, captures the full inspected config, stops and renames the old container, creates and starts the new one with identical settings, volumes and networks, and rolls back to the old container if the new one fails its health check"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+		mcp.WithString("image",
+			mcp.Description("New image reference, e.g. nginx:1.27. Defaults to the container's current image reference (re-pulls the same tag)")),
+		mcp.WithString("pull",
+			mcp.DefaultString(api.PullAlways),
+			mcp.Enum(api.PullAlways, api.PullMissing, api.PullNever),
+			mcp.Description("When to pull the image: always (default), missing or never")),
+		mcp.WithNumber("stopTimeout",
+			mcp.Description("Seconds to wait for the old container to stop before killing it")),
+		mcp.WithNumber("healthTimeout",
+			mcp.DefaultNumber(api.DefaultHealthTimeout.Seconds()),
+			mcp.Description("Seconds to wait for the new container to become healthy before rolling back")),
+		mcp.WithBoolean("keepOld",
+			mcp.DefaultBool(false),
+			mcp.Description("Keep the renamed old container after a successful upgrade instead of removing it")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		opts := api.RecreateOptions{Pull: api.PullAlways}
+		if val, ok := request.Params.Arguments["image"].(string); ok {
+			opts.Image = val
+		}
+		if val, ok := request.Params.Arguments["pull"].(string); ok && val != "" {
+			opts.Pull = val
+		}
+		if val, ok := request.Params.Arguments["stopTimeout"].(float64); ok {
+			timeout := int(val)
+			opts.StopTimeout = &timeout
+		}
+		if val, ok := request.Params.Arguments["healthTimeout"].(float64); ok {
+			opts.HealthTimeout = time.Duration(val * float64(time.Second))
+		}
+		if val, ok := request.Params.Arguments["keepOld"].(bool); ok {
+			opts.KeepOld = val
+		}
+		logs.InfoObjects("mcp_docker_container_recreate called", "id", id, "options", opts)
+		recreate, err := api.RecreateContainer(ctx, cli, id, opts)
+		if err != nil {
+			logs.ErrorWithFields("RecreateContainer failed", map[string]interface{}{"id": id, "error": err})
+			// 已经开始重建时返回回滚结果，说明旧容器是否已恢复
+			if recreate == nil {
+				return nil, err
+			}
+		}
+		payload := map[string]interface{}{
+			"status": "success",
+			"data":   recreate,
+		}
+		if err != nil {
+			payload["status"] = "failed"
+			payload["error"] = err.Error()
+		}
+		result, _ := json.Marshal(payload)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+			IsError: err != nil,
+		}, nil
+	})
+}
+
+func RegisterContainerCloneTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_clone",
+		mcp.WithDescription("Clone a container under a new name with the same configuration - useful for reproducing issues next to the original - Optionally remaps published ports to free host ports, shares or copies volumes into fresh named volumes, and can start from a committed snapshot of the source container's filesystem"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Source container ID or container name")),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the cloned container")),
+		mcp.WithBoolean("remapPorts",
+			mcp.DefaultBool(true),
+			mcp.Description("Publish the same container ports on free host ports chosen by Docker instead of the source's host ports")),
+		mcp.WithString("volumes",
+			mcp.DefaultString(api.CloneVolumesShare),
+			mcp.Enum(api.CloneVolumesShare, api.CloneVolumesCopy),
+			mcp.Description("share: mount the source's named volumes as-is; copy: copy their data into new named volumes (the source is paused during the copy). Bind mounts are always shared")),
+		mcp.WithBoolean("snapshot",
+			mcp.DefaultBool(false),
+			mcp.Description("Commit the source container's current filesystem to an image and create the clone from it")),
+		mcp.WithBoolean("start",
+			mcp.DefaultBool(true),
+			mcp.Description("Start the clone after creating it")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		name, ok := request.Params.Arguments["name"].(string)
+		if !ok || name == "" {
+			return nil, errors.New("name parameter is required and must be a string")
+		}
+		opts := api.CloneOptions{Name: name, RemapPorts: true, Volumes: api.CloneVolumesShare, Start: true}
+		if val, ok := request.Params.Arguments["remapPorts"].(bool); ok {
+			opts.RemapPorts = val
+		}
+		if val, ok := request.Params.Arguments["volumes"].(string); ok && val != "" {
+			opts.Volumes = val
+		}
+		if val, ok := request.Params.Arguments["snapshot"].(bool); ok {
+			opts.Snapshot = val
+		}
+		if val, ok := request.Params.Arguments["start"].(bool); ok {
+			opts.Start = val
+		}
+		logs.InfoObjects("mcp_docker_container_clone called", "id", id, "options", opts)
+		clone, err := api.CloneContainer(ctx, cli, id, opts)
+		if err != nil {
+			logs.ErrorWithFields("CloneContainer failed", map[string]interface{}{"id": id, "name": name, "error": err})
+			// 已经创建过容器或卷时返回清理结果
+			if clone == nil {
+				return nil, err
+			}
+		}
+		payload := map[string]interface{}{
+			"status": "success",
+			"data":   clone,
+		}
+		if err != nil {
+			payload["status"] = "failed"
+			payload["error"] = err.Error()
+		}
+		result, _ := json.Marshal(payload)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+			IsError: err != nil,
+		}, nil
+	})
+}
+
+func RegisterContainerCommitTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_commit",
+		mcp.WithDescription("Create a new image from a container's changes - equivalent to 'docker commit' - Snapshots the container's current filesystem and configuration, e.g. to save a debug state before experimenting. Returns the new image ID and size"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+		mcp.WithString("repository",
+			mcp.Description("Repository of the new image, e.g. myapp-debug. Leave empty to create an untagged image")),
+		mcp.WithString("tag",
+			mcp.Description("Tag of the new image (default latest when repository is set). Requires repository")),
+		mcp.WithString("author",
+			mcp.Description("Author, e.g. \"John Doe <john@example.com>\"")),
+		mcp.WithString("message",
+			mcp.Description("Commit message")),
+		mcp.WithArray("changes",
+			mcp.Items(map[string]any{"type": "string"}),
+			mcp.Description("Dockerfile instructions applied to the image config, e.g. [\"CMD [\\\"sh\\\"]\", \"ENV DEBUG=1\", \"LABEL stage=debug\"]")),
+		mcp.WithBoolean("pause",
+			mcp.DefaultBool(true),
+			mcp.Description("Pause the container during commit")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		opts := api.CommitOptions{Pause: true}
+		repository, _ := request.Params.Arguments["repository"].(string)
+		tag, _ := request.Params.Arguments["tag"].(string)
+		if tag != "" && repository == "" {
+			return nil, errors.New("tag requires repository")
+		}
+		if repository != "" {
+			opts.Reference = repository
+			if tag != "" {
+				opts.Reference += ":" + tag
+			}
+		}
+		if val, ok := request.Params.Arguments["author"].(string); ok {
+			opts.Author = val
+		}
+		if val, ok := request.Params.Arguments["message"].(string); ok {
+			opts.Message = val
+		}
+		if val, ok := request.Params.Arguments["changes"].([]any); ok {
+			for _, change := range val {
+				if str, ok := change.(string); ok {
+					opts.Changes = append(opts.Changes, str)
+				}
+			}
+		}
+		if val, ok := request.Params.Arguments["pause"].(bool); ok {
+			opts.Pause = val
+		}
+		logs.InfoObjects("mcp_docker_container_commit called", "id", id, "options", opts)
+		commit, err := api.ContainerCommit(ctx, cli, id, opts)
+		if err != nil {
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   commit,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterContainerUpdateTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_update",
+		mcp.WithDescription("Update resource limits of a running container without recreating it - equivalent to 'docker update' - Changes memory/swap, CPU shares/quota/cpuset, pids limit, blkio weight and restart policy, and returns the values before and after"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+		mcp.WithString("memory",
+			mcp.Description("Memory limit, e.g. 512m or 2g")),
+		mcp.WithString("memorySwap",
+			mcp.Description("Total memory plus swap limit, e.g. 1g, or -1 for unlimited swap. Must be at least memory")),
+		mcp.WithString("memoryReservation",
+			mcp.Description("Memory soft limit, e.g. 256m")),
+		mcp.WithNumber("cpuShares",
+			mcp.Description("Relative CPU weight, e.g. 512 (default weight is 1024)")),
+		mcp.WithNumber("cpus",
+			mcp.Description("Number of CPUs, e.g. 0.5. Must be greater than 0; a CPU limit cannot be removed with docker update. Cannot be combined with cpuPeriod/cpuQuota")),
+		mcp.WithNumber("cpuPeriod",
+			mcp.Description("CFS period in microseconds, e.g. 100000")),
+		mcp.WithNumber("cpuQuota",
+			mcp.Description("CFS quota in microseconds per period, e.g. 50000 for half a CPU")),
+		mcp.WithString("cpusetCpus",
+			mcp.Description("CPUs the container may run on, e.g. 0-2 or 0,1")),
+		mcp.WithString("cpusetMems",
+			mcp.Description("Memory nodes the container may use, e.g. 0")),
+		mcp.WithNumber("pidsLimit",
+			mcp.Description("Maximum number of processes, -1 for unlimited")),
+		mcp.WithNumber("blkioWeight",
+			mcp.Description("Relative block IO weight between 10 and 1000. The weight cannot be reset with docker update")),
+		mcp.WithString("restartPolicy",
+			mcp.Description("Restart policy: no, always, unless-stopped or on-failure[:max-retries]")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		var opts api.UpdateOptions
+		if err := bindArguments(request, &opts); err != nil {
+			return nil, err
+		}
+		logs.InfoObjects("mcp_docker_container_update called", "id", id, "options", opts)
+		updated, err := api.ContainerUpdate(ctx, cli, id, opts)
+		if err != nil {
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   updated,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterContainerDiagnoseTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_diagnose",
+		mcp.WithDescription("Diagnose an unhealthy, crashing or unreachable container in one call - combines 'docker inspect', 'docker logs', 'docker events' and 'docker stats' - Returns state (exit code, OOMKilled, restart count), healthcheck log, last log lines, recent events, resource usage versus limits, port reachability, and likely causes such as OOM, port already allocated, missing env var or failing healthcheck"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+		mcp.WithNumber("logLines",
+			mcp.DefaultNumber(api.DefaultDiagnoseLogLines),
+			mcp.Min(1),
+			mcp.Description("Number of most recent log lines to include (default 50)")),
+		mcp.WithString("eventsSince",
+			mcp.DefaultString(api.DefaultDiagnoseEvents.String()),
+			mcp.Description("How far back to collect container events, as a Go duration, e.g. 30m or 24h (default 1h)")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		opts := api.DiagnoseOptions{LogLines: api.DefaultDiagnoseLogLines, EventsSince: api.DefaultDiagnoseEvents}
+		if val, ok := request.Params.Arguments["logLines"].(float64); ok {
+			opts.LogLines = int(val)
+		}
+		if val, ok := request.Params.Arguments["eventsSince"].(string); ok && val != "" {
+			since, err := time.ParseDuration(val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid eventsSince %q: %v", val, err)
+			}
+			opts.EventsSince = since
+		}
+		logs.InfoWithFields("mcp_docker_container_diagnose called", map[string]interface{}{"id": id, "logLines": opts.LogLines, "eventsSince": opts.EventsSince.String()})
+		report, err := api.DiagnoseContainer(ctx, cli, id, opts)
+		if err != nil {
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   report,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterContainerListTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	stringArray := map[string]any{"type": "string"}
+	tool := mcp.NewTool("mcp_docker_container_list",
+		mcp.WithDescription("List containers - equivalent to 'docker ps -a' - Shows all containers (running and stopped) with state, status, labels, mounts and networks. Supports Docker filters, sorting, a limit and a field projection for large hosts"),
+		mcp.WithBoolean("all",
+			mcp.DefaultBool(true),
+			mcp.Description("Include stopped containers (default true)")),
+		mcp.WithArray("status",
+			mcp.Items(stringArray),
+			mcp.Description("Filter by status: created, restarting, running, removing, paused, exited or dead")),
+		mcp.WithArray("label",
+			mcp.Items(stringArray),
+			mcp.Description("Filter by label, key or key=value, e.g. [\"com.docker.compose.project=web\"]")),
+		mcp.WithString("name",
+			mcp.Description("Filter by container name (substring match)")),
+		mcp.WithString("ancestor",
+			mcp.Description("Filter by image the container was created from, e.g. nginx or nginx:1.27")),
+		mcp.WithString("network",
+			mcp.Description("Filter by network name or ID")),
+		mcp.WithString("health",
+			mcp.Enum("starting", "healthy", "unhealthy", "none"),
+			mcp.Description("Filter by health status")),
+		mcp.WithNumber("exited",
+			mcp.Description("Filter by exit code of exited containers, e.g. 137")),
+		mcp.WithString("sort",
+			mcp.DefaultString("created"),
+			mcp.Enum("created", "name", "image", "state"),
+			mcp.Description("Sort key (default created, newest first)")),
+		mcp.WithString("order",
+			mcp.Enum("asc", "desc"),
+			mcp.Description("Sort order. Defaults to desc for created and asc otherwise")),
+		mcp.WithNumber("limit",
+			mcp.Min(0),
+			mcp.Description("Return at most this many containers after sorting")),
+		mcp.WithArray("fields",
+			mcp.Items(stringArray),
+			mcp.Description("Only return these fields, e.g. [\"ID\", \"Names\", \"State\"]. Available: ID, Names, Command, Created, Ports, Image, State, Status, Labels, Mounts, Networks")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var opts api.ContainerListOptions
+		if err := bindArguments(request, &opts); err != nil {
+			return nil, err
+		}
+		var args struct {
+			Fields []string `json:"fields"`
+		}
+		if err := bindArguments(request, &args); err != nil {
+			return nil, err
+		}
+		containers, err := api.ListContainers(ctx, cli, opts)
+		if err != nil {
+			return nil, err
+		}
+		var result []byte
+		if len(args.Fields) > 0 {
+			projected, err := api.ProjectFields(containers, args.Fields)
+			if err != nil {
+				return nil, err
+			}
+			result, _ = json.Marshal(projected)
+		} else {
+			result, _ = json.Marshal(containers)
 		}
-		result, _ := json.Marshal(containers)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{
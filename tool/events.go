@@ -0,0 +1,133 @@
+package tool
+
+import (
+	"context"
+	"docker-mcp/api"
+	"docker-mcp/cmd/logs"
+	"docker-mcp/resp"
+	"encoding/json"
+	"fmt"
+	"github.com/docker/docker/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"time"
+)
+
+const (
+	defaultEventsLimit     = 200
+	defaultSubscribeWindow = 60
+	// maxSubscribeWindow 订阅会占用整个请求，限制最长时间
+	maxSubscribeWindow = 600
+)
+
+func RegisterEventsTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	stringArray := map[string]any{"type": "string"}
+	tool := mcp.NewTool("mcp_docker_events",
+		mcp.WithDescription("Get real-time or historical events from the Docker daemon - equivalent to 'docker events' - query mode returns past events between since and until; subscribe mode pushes each matching event to the client as a notifications/message notification for a bounded time, so agents can react to e.g. a container dying without polling"),
+		mcp.WithString("mode",
+			mcp.DefaultString("query"),
+			mcp.Enum("query", "subscribe"),
+			mcp.Description("query: return historical events; subscribe: stream new events as notifications until duration elapses or maxEvents is reached")),
+		mcp.WithString("since",
+			mcp.Description("Start of the range: RFC3339 time, unix timestamp, or duration ago such as 30m. Required in query mode (default 1h)")),
+		mcp.WithString("until",
+			mcp.Description("End of the range in query mode, same formats as since (default now)")),
+		mcp.WithArray("type",
+			mcp.Items(stringArray),
+			mcp.Description("Filter by object type: container, image, volume, network, daemon, plugin, node, service, secret, config")),
+		mcp.WithArray("action",
+			mcp.Items(stringArray),
+			mcp.Description("Filter by action, e.g. [\"die\", \"oom\", \"health_status\", \"start\"]")),
+		mcp.WithArray("container",
+			mcp.Items(stringArray),
+			mcp.Description("Filter by container ID or name")),
+		mcp.WithArray("image",
+			mcp.Items(stringArray),
+			mcp.Description("Filter by image name or ID")),
+		mcp.WithArray("label",
+			mcp.Items(stringArray),
+			mcp.Description("Filter by label, key or key=value")),
+		mcp.WithNumber("duration",
+			mcp.DefaultNumber(defaultSubscribeWindow),
+			mcp.Min(1),
+			mcp.Max(maxSubscribeWindow),
+			mcp.Description("Seconds to stay subscribed in subscribe mode (default 60, max 600)")),
+		mcp.WithNumber("maxEvents",
+			mcp.DefaultNumber(defaultEventsLimit),
+			mcp.Min(1),
+			mcp.Description("query: return at most this many most recent events; subscribe: stop after this many events (default 200)")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var opts api.EventsOptions
+		if err := bindArguments(request, &opts); err != nil {
+			return nil, err
+		}
+		mode := "query"
+		if val, ok := request.Params.Arguments["mode"].(string); ok && val != "" {
+			mode = val
+		}
+		maxEvents := defaultEventsLimit
+		if val, ok := request.Params.Arguments["maxEvents"].(float64); ok && val > 0 {
+			maxEvents = int(val)
+		}
+		logs.InfoObjects("mcp_docker_events called", "mode", mode, "options", opts, "maxEvents", maxEvents)
+
+		var data map[string]interface{}
+		switch mode {
+		case "query":
+			if opts.Since == "" {
+				opts.Since = "1h"
+			}
+			evts, truncated, err := api.QueryEvents(ctx, cli, opts, maxEvents)
+			if err != nil {
+				logs.ErrorWithFields("QueryEvents failed", map[string]interface{}{"error": err})
+				return nil, err
+			}
+			data = map[string]interface{}{"events": evts, "count": len(evts), "truncated": truncated}
+		case "subscribe":
+			duration := defaultSubscribeWindow
+			if val, ok := request.Params.Arguments["duration"].(float64); ok && val > 0 {
+				duration = min(int(val), maxSubscribeWindow)
+			}
+			// 订阅只接收新事件
+			opts.Since, opts.Until = "", ""
+			watchCtx, cancel := context.WithTimeout(ctx, time.Duration(duration)*time.Second)
+			defer cancel()
+			received := make([]resp.Event, 0)
+			notifyFailed := 0
+			err := api.WatchEvents(watchCtx, cli, opts, func(event resp.Event) bool {
+				received = append(received, event)
+				if err := srv.SendNotificationToClient(ctx, "notifications/message", map[string]any{
+					"level":  "info",
+					"logger": "docker-events",
+					"data":   event,
+				}); err != nil {
+					notifyFailed++
+				}
+				return len(received) < maxEvents
+			})
+			if err != nil {
+				logs.ErrorWithFields("WatchEvents failed", map[string]interface{}{"error": err})
+				return nil, err
+			}
+			data = map[string]interface{}{"events": received, "count": len(received), "duration": duration}
+			if notifyFailed > 0 {
+				data["notificationsFailed"] = notifyFailed
+			}
+		default:
+			return nil, fmt.Errorf("unsupported mode %q, expected query or subscribe", mode)
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   data,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
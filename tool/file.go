@@ -0,0 +1,210 @@
+package tool
+
+import (
+	"context"
+	"docker-mcp/api"
+	"docker-mcp/cmd/logs"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func RegisterContainerFileTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	RegisterContainerFileReadTool(ctx, srv, cli)
+	RegisterContainerFileWriteTool(ctx, srv, cli)
+	RegisterContainerLsTool(ctx, srv, cli)
+}
+
+func RegisterContainerFileReadTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_file_read",
+		mcp.WithDescription("Read a file or directory from a container - equivalent to 'docker cp <container>:<path> -' - Text files are returned as-is, binary files as base64, directories as an entry listing. Works on stopped containers and images without a shell"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Absolute path inside the container, e.g. /etc/nginx/nginx.conf")),
+		mcp.WithNumber("maxBytes",
+			mcp.DefaultNumber(api.DefaultMaxFileBytes),
+			mcp.Description("Refuse to read files larger than this many bytes (default 1MiB)")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		filePath, ok := request.Params.Arguments["path"].(string)
+		if !ok || filePath == "" {
+			return nil, errors.New("path parameter is required and must be a string")
+		}
+		maxBytes := int64(api.DefaultMaxFileBytes)
+		if val, ok := request.Params.Arguments["maxBytes"].(float64); ok {
+			maxBytes = int64(val)
+		}
+		logs.InfoWithFields("mcp_docker_container_file_read called", map[string]interface{}{"id": id, "path": filePath, "maxBytes": maxBytes})
+		file, err := api.ReadContainerPath(ctx, cli, id, filePath, maxBytes)
+		if err != nil {
+			logs.ErrorWithFields("ReadContainerPath failed", map[string]interface{}{"id": id, "path": filePath, "error": err})
+			return nil, err
+		}
+		result, _ := json.Marshal(file)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterContainerFileWriteTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_file_write",
+		mcp.WithDescription("Write a file into a container - equivalent to 'docker cp <file> <container>:<path>' - Creates or overwrites a single file, keeping the existing mode and owner unless new ones are given. The parent directory must exist"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Absolute path of the file inside the container")),
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("File content, plain text or base64 depending on encoding")),
+		mcp.WithString("encoding",
+			mcp.DefaultString("text"),
+			mcp.Enum("text", "base64"),
+			mcp.Description("Encoding of content: text or base64 (for binary files)")),
+		mcp.WithString("mode",
+			mcp.Description("Octal file mode, e.g. 0644. Defaults to the existing file's mode, or 0644 for new files")),
+		mcp.WithString("owner",
+			mcp.Description("Numeric owner in format uid[:gid], e.g. 101:101. Defaults to the existing file's owner, or 0:0 for new files")),
+		mcp.WithNumber("maxBytes",
+			mcp.DefaultNumber(api.DefaultMaxFileBytes),
+			mcp.Description("Refuse to write content larger than this many bytes (default 1MiB)")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		filePath, ok := request.Params.Arguments["path"].(string)
+		if !ok || filePath == "" {
+			return nil, errors.New("path parameter is required and must be a string")
+		}
+		content, ok := request.Params.Arguments["content"].(string)
+		if !ok {
+			return nil, errors.New("content parameter is required and must be a string")
+		}
+		encoding := "text"
+		if val, ok := request.Params.Arguments["encoding"].(string); ok && val != "" {
+			encoding = val
+		}
+		opts := api.FileWriteOptions{MaxBytes: api.DefaultMaxFileBytes}
+		if val, ok := request.Params.Arguments["mode"].(string); ok {
+			opts.Mode = val
+		}
+		if val, ok := request.Params.Arguments["owner"].(string); ok {
+			opts.Owner = val
+		}
+		if val, ok := request.Params.Arguments["maxBytes"].(float64); ok {
+			opts.MaxBytes = int64(val)
+		}
+		logs.InfoWithFields("mcp_docker_container_file_write called", map[string]interface{}{
+			"id": id, "path": filePath, "encoding": encoding, "mode": opts.Mode, "owner": opts.Owner,
+		})
+
+		data := []byte(content)
+		switch encoding {
+		case "text":
+		case "base64":
+			decoded, err := base64.StdEncoding.DecodeString(content)
+			if err != nil {
+				return nil, fmt.Errorf("decode base64 content failed: %v", err)
+			}
+			data = decoded
+		default:
+			return nil, fmt.Errorf("unsupported encoding %q", encoding)
+		}
+
+		written, err := api.WriteContainerFile(ctx, cli, id, filePath, data, opts)
+		if err != nil {
+			logs.ErrorWithFields("WriteContainerFile failed", map[string]interface{}{"id": id, "path": filePath, "error": err})
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   written,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterContainerLsTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_ls",
+		mcp.WithDescription("List a directory inside a container without exec - equivalent to 'ls -l' but works on distroless and scratch images that have no shell - Returns name, size, mode, mtime and link target of each entry"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Absolute directory path inside the container, e.g. /etc. The daemon streams the whole subtree, so prefer the most specific directory")),
+		mcp.WithNumber("depth",
+			mcp.DefaultNumber(1),
+			mcp.Min(0),
+			mcp.Description("Recursion depth: 1 lists direct children only, 0 means unlimited")),
+		mcp.WithString("pattern",
+			mcp.Description("Glob filter, e.g. *.conf. Matches the entry name, or the relative path when the pattern contains '/'")),
+		mcp.WithNumber("limit",
+			mcp.DefaultNumber(api.DefaultDirListLimit),
+			mcp.Description("Maximum number of entries to return (default 1000). At most 10 times this many entries are scanned whatever the filters; truncated is set when the listing stops early")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		dir, ok := request.Params.Arguments["path"].(string)
+		if !ok || dir == "" {
+			return nil, errors.New("path parameter is required and must be a string")
+		}
+		opts := api.DirListOptions{Depth: 1, Limit: api.DefaultDirListLimit}
+		if val, ok := request.Params.Arguments["depth"].(float64); ok {
+			opts.Depth = int(val)
+		}
+		if val, ok := request.Params.Arguments["pattern"].(string); ok {
+			opts.Pattern = val
+		}
+		if val, ok := request.Params.Arguments["limit"].(float64); ok {
+			opts.Limit = int(val)
+		}
+		logs.InfoWithFields("mcp_docker_container_ls called", map[string]interface{}{
+			"id": id, "path": dir, "depth": opts.Depth, "pattern": opts.Pattern, "limit": opts.Limit,
+		})
+		listing, err := api.ListContainerPath(ctx, cli, id, dir, opts)
+		if err != nil {
+			logs.ErrorWithFields("ListContainerPath failed", map[string]interface{}{"id": id, "path": dir, "error": err})
+			return nil, err
+		}
+		result, _ := json.Marshal(listing)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
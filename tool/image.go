@@ -4,8 +4,9 @@ import (
 	"context"
 	"docker-mcp/api"
 	"docker-mcp/cmd/logs"
-	"docker-mcp/resp"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -13,40 +14,106 @@ import (
 	"strings"
 )
 
-func RegisterImageTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+func RegisterImageTool(ctx context.Context, srv *server.MCPServer, cli *client.Client, archiveDir string) {
 	logs.Info("RegisterImageTool called")
 	RegisterImageListTool(ctx, srv, cli)
 	RegisterImagePullTool(ctx, srv, cli)
 	RegisterImageRemoveTool(ctx, srv, cli)
 	RegisterImageRemoveBatchTool(ctx, srv, cli)
 	RegisterImageDetailsTool(ctx, srv, cli)
+	RegisterImageBuildTool(ctx, srv, cli, archiveDir)
+	RegisterImagePushTool(ctx, srv, cli)
+	RegisterImageTagTool(ctx, srv, cli)
+	RegisterImageUntagTool(ctx, srv, cli)
+	RegisterImageHistoryTool(ctx, srv, cli)
+	RegisterImagePruneTool(ctx, srv, cli)
+	RegisterImageSearchTool(ctx, srv, cli)
+	RegisterImageRemoteTagsTool(ctx, srv, cli)
 }
 
 func RegisterImageRemoveBatchTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
 	tool := mcp.NewTool("mcp_docker_image_remove_batch",
-		mcp.WithDescription("Remove multiple Docker images in batch - equivalent to 'docker rmi <image1> <image2>' - Deletes specified images from the system"),
+		mcp.WithDescription("Remove multiple Docker images in batch - equivalent to 'docker rmi <image1> <image2>' - Deletes specified images from the system, continuing past failures and reporting the result of each image"),
 		mcp.WithString("ids",
 			mcp.Description("Comma-separated list of image names or IDs to remove, e.g., redis:v1.0.0,hello-world:latest")),
+		mcp.WithBoolean("force",
+			mcp.DefaultBool(true),
+			mcp.Description("Force removal of images used by stopped containers or with multiple tags")),
 	)
 	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		ids := request.Params.Arguments["ids"].(string)
+		ids, ok := request.Params.Arguments["ids"].(string)
+		if !ok || strings.TrimSpace(ids) == "" {
+			return nil, errors.New("ids parameter is required and must be a string")
+		}
+		force := true
+		if val, ok := request.Params.Arguments["force"].(bool); ok {
+			force = val
+		}
 		logs.Info("mcp_docker_image_remove_batch called, ids: %s", ids)
-		split := strings.Split(ids, ",")
-		responses := make([]image.DeleteResponse, 0)
-		for _, val := range split {
-			logs.Info("Removing image: %s", val)
-			res, err := cli.ImageRemove(ctx, val, image.RemoveOptions{
-				Force: true,
-			})
-			if err != nil {
-				logs.Error("Remove image failed: %s, error: %s", val, err.Error())
-				return nil, err
+		refs := make([]string, 0)
+		for _, val := range strings.Split(ids, ",") {
+			if val = strings.TrimSpace(val); val != "" {
+				refs = append(refs, val)
+			}
+		}
+		removed := api.RemoveImages(ctx, cli, refs, force)
+		succeeded := 0
+		for _, r := range removed {
+			if r.Removed {
+				succeeded++
 			}
-			logs.Info("Remove image success: %s", val)
-			responses = append(responses, res...)
 		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": batchStatus(succeeded, len(removed)),
+			"data":   removed,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
 
-		result, _ := json.Marshal(responses)
+func RegisterImagePruneTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_image_prune",
+		mcp.WithDescription("Remove unused images - equivalent to 'docker image prune' - Removes dangling images by default, or all images not used by any container with all=true. Supports until and label filters and returns the reclaimed space"),
+		mcp.WithBoolean("all",
+			mcp.DefaultBool(false),
+			mcp.Description("Remove all unused images, not just dangling ones")),
+		mcp.WithString("until",
+			mcp.Description("Only remove images created before this time: unix timestamp, RFC3339 time or duration such as 24h")),
+		mcp.WithArray("label",
+			mcp.Items(map[string]any{"type": "string"}),
+			mcp.Description("Label filters: key or key=value to include, label!=key[=value] to exclude")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var opts api.ImagePruneOptions
+		if val, ok := request.Params.Arguments["all"].(bool); ok {
+			opts.All = val
+		}
+		if val, ok := request.Params.Arguments["until"].(string); ok {
+			opts.Until = val
+		}
+		if val, ok := request.Params.Arguments["label"].([]any); ok {
+			for _, label := range val {
+				if str, ok := label.(string); ok && str != "" {
+					opts.Label = append(opts.Label, str)
+				}
+			}
+		}
+		logs.InfoObjects("mcp_docker_image_prune called", "options", opts)
+		pruned, err := api.PruneImages(ctx, cli, opts)
+		if err != nil {
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   pruned,
+		})
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{
@@ -89,20 +156,42 @@ func RegisterImageRemoveTool(ctx context.Context, srv *server.MCPServer, cli *cl
 
 func RegisterImagePullTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
 	tool := mcp.NewTool("mcp_docker_image_pull",
-		mcp.WithDescription("Pull a Docker image - equivalent to 'docker pull <image>' - Downloads an image from a registry"),
+		mcp.WithDescription("Pull a Docker image - equivalent to 'docker pull <image>' - Downloads an image from a registry, authenticating with the stored credentials for its registry. Returns a per-layer summary and the resolved digest, and sends progress notifications when the client provides a progress token"),
 		mcp.WithString("image",
-			mcp.Description("Image name to pull with optional tag")),
+			mcp.Required(),
+			mcp.Description("Image name to pull with optional tag or digest. Defaults to tag latest")),
+		mcp.WithString("platform",
+			mcp.Description("Platform to pull if the image is multi-platform, e.g. linux/arm64")),
+		mcp.WithBoolean("allTags",
+			mcp.DefaultBool(false),
+			mcp.Description("Pull all tags of the repository; image must not include a tag")),
 	)
 	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		name := request.Params.Arguments["image"].(string)
-		logs.Info("mcp_docker_image_pull called, image: %s", name)
-		pullImage, err := api.PullImage(ctx, cli, name)
+		name, ok := request.Params.Arguments["image"].(string)
+		if !ok || name == "" {
+			return nil, errors.New("image parameter is required and must be a string")
+		}
+		var opts api.PullOptions
+		opts.Platform, _ = request.Params.Arguments["platform"].(string)
+		opts.AllTags, _ = request.Params.Arguments["allTags"].(bool)
+		logs.InfoWithFields("mcp_docker_image_pull called", map[string]interface{}{"image": name, "platform": opts.Platform, "allTags": opts.AllTags})
+		notify := progressNotifier(ctx, srv, request)
+		var total int64
+		pull, err := api.PullImage(ctx, cli, name, opts, func(progress *api.LayerProgress) {
+			var current int64
+			var done, count int
+			current, total, done, count = progress.Totals()
+			notify(float64(current), float64(total), fmt.Sprintf("pulled %d/%d layers", done, count), false)
+		})
 		if err != nil {
 			logs.Error("Pull image failed: %s, error: %s", name, err.Error())
 			return nil, err
 		}
-		logs.Info("Pull image success: %s", name)
-		result, _ := json.Marshal(pullImage)
+		notify(float64(total), float64(total), "pull complete "+pull.Digest, true)
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   pull,
+		})
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{
@@ -115,36 +204,46 @@ func RegisterImagePullTool(ctx context.Context, srv *server.MCPServer, cli *clie
 }
 
 func RegisterImageListTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	stringArray := map[string]any{"type": "string"}
 	tool := mcp.NewTool("mcp_docker_image_list",
-		mcp.WithDescription("List all Docker images - equivalent to 'docker image ls' - Shows all images stored locally on the system"),
+		mcp.WithDescription("List Docker images - equivalent to 'docker image ls --digests' - Returns one entry per repository:tag with registry, repository and tag split out, repo digests, untagged top-level images flagged as dangling and untagged parent layers flagged as intermediate. Supports Docker filters, sorting and a limit"),
+		mcp.WithBoolean("all",
+			mcp.DefaultBool(true),
+			mcp.Description("Include intermediate images (default true). Intermediate images are flagged Intermediate and never Dangling")),
+		mcp.WithArray("reference",
+			mcp.Items(stringArray),
+			mcp.Description("Filter by reference pattern, e.g. [\"nginx\", \"registry:5000/app:1.*\"]")),
+		mcp.WithArray("label",
+			mcp.Items(stringArray),
+			mcp.Description("Filter by label, key or key=value")),
+		mcp.WithBoolean("dangling",
+			mcp.Description("Only dangling images when true, only tagged images when false")),
+		mcp.WithString("before",
+			mcp.Description("Only images created before this image reference or ID")),
+		mcp.WithString("since",
+			mcp.Description("Only images created after this image reference or ID")),
+		mcp.WithString("sort",
+			mcp.DefaultString("created"),
+			mcp.Enum("created", "size", "reference"),
+			mcp.Description("Sort key (default created, newest first)")),
+		mcp.WithString("order",
+			mcp.Enum("asc", "desc"),
+			mcp.Description("Sort order. Defaults to asc for reference and desc otherwise")),
+		mcp.WithNumber("limit",
+			mcp.Min(0),
+			mcp.Description("Return at most this many entries after sorting")),
 	)
 	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		logs.Info("mcp_docker_image_list called")
-		list, err := cli.ImageList(ctx, image.ListOptions{
-			All: true,
-		})
-		if err != nil {
-			logs.Error("ImageList failed: %s", err.Error())
+		var opts api.ImageListOptions
+		if err := bindArguments(request, &opts); err != nil {
 			return nil, err
 		}
-		logs.Info("ImageList success, count: %d", len(list))
-		images := make([]resp.Image, 0)
-		for _, summary := range list {
-			tag := ""
-			if len(summary.RepoTags) > 0 {
-				parts := strings.SplitN(summary.RepoTags[0], ":", 2)
-				if len(parts) == 2 {
-					tag = parts[1]
-				}
-			}
-			images = append(images, resp.Image{
-				Repository: summary.RepoTags,
-				Tag:        tag,
-				ImageID:    summary.ID,
-				Created:    summary.Created,
-				Size:       summary.Size,
-			})
+		logs.InfoObjects("mcp_docker_image_list called", "options", opts)
+		images, err := api.ListImages(ctx, cli, opts)
+		if err != nil {
+			return nil, err
 		}
+		logs.Info("ImageList success, count: %d", len(images))
 		result, _ := json.Marshal(images)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -183,3 +282,342 @@ func RegisterImageDetailsTool(ctx context.Context, srv *server.MCPServer, cli *c
 		}, nil
 	})
 }
+
+func RegisterImageBuildTool(ctx context.Context, srv *server.MCPServer, cli *client.Client, archiveDir string) {
+	stringArray := map[string]any{"type": "string"}
+	stringMap := map[string]any{"type": "string"}
+	tool := mcp.NewTool("mcp_docker_image_build",
+		mcp.WithDescription("Build an image from a Dockerfile - equivalent to 'docker build' - Sends a context directory on the MCP server host (honouring .dockerignore) and/or an inline Dockerfile to the daemon. Returns the image ID and the build output summarized per step, with the failing step's output highlighted on error"),
+		mcp.WithString("contextDir",
+			mcp.Description("Build context directory on the MCP server host. Must be inside the server's archive directory (--archive-dir); relative paths are resolved against it, e.g. app. Optional when dockerfileContent is given")),
+		mcp.WithString("dockerfile",
+			mcp.DefaultString("Dockerfile"),
+			mcp.Description("Path of the Dockerfile relative to contextDir (default Dockerfile)")),
+		mcp.WithString("dockerfileContent",
+			mcp.Description("Inline Dockerfile content. Takes precedence over dockerfile")),
+		mcp.WithObject("buildArgs",
+			mcp.AdditionalProperties(stringMap),
+			mcp.Description("Build-time variables, e.g. {\"VERSION\": \"1.2.3\"}")),
+		mcp.WithString("target",
+			mcp.Description("Target build stage of a multi-stage Dockerfile")),
+		mcp.WithArray("tags",
+			mcp.Items(stringArray),
+			mcp.Description("Image references to tag the result with, e.g. [\"myapp:1.2.3\", \"myapp:latest\"]")),
+		mcp.WithObject("labels",
+			mcp.AdditionalProperties(stringMap),
+			mcp.Description("Labels to set on the image")),
+		mcp.WithBoolean("noCache",
+			mcp.DefaultBool(false),
+			mcp.Description("Do not use the build cache")),
+		mcp.WithBoolean("pull",
+			mcp.DefaultBool(false),
+			mcp.Description("Always attempt to pull newer versions of base images")),
+		mcp.WithString("platform",
+			mcp.Description("Target platform, e.g. linux/amd64")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var opts api.BuildOptions
+		if err := bindArguments(request, &opts); err != nil {
+			return nil, err
+		}
+		logs.InfoObjects("mcp_docker_image_build called", "contextDir", opts.ContextDir, "dockerfile", opts.Dockerfile, "tags", opts.Tags, "target", opts.Target)
+		build, err := api.BuildImage(ctx, cli, archiveDir, opts)
+		if err != nil {
+			return nil, err
+		}
+		status := "success"
+		if build.Error != "" {
+			status = "failed"
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": status,
+			"data":   build,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+			IsError: build.Error != "",
+		}, nil
+	})
+}
+
+func RegisterImagePushTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_image_push",
+		mcp.WithDescription("Push an image to a registry - equivalent to 'docker push <image>' - Uses the credentials stored by mcp_docker_auth_registry for the image's registry. Sends progress notifications when the client provides a progress token and returns the pushed digest"),
+		mcp.WithString("image",
+			mcp.Required(),
+			mcp.Description("Image reference to push, e.g. registry.example.com/team/app:1.2.3. Defaults to tag latest")),
+		mcp.WithBoolean("allTags",
+			mcp.DefaultBool(false),
+			mcp.Description("Push all tags of the repository; image must not include a tag")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		imageRef, ok := request.Params.Arguments["image"].(string)
+		if !ok || imageRef == "" {
+			return nil, errors.New("image parameter is required and must be a string")
+		}
+		allTags, _ := request.Params.Arguments["allTags"].(bool)
+		logs.InfoWithFields("mcp_docker_image_push called", map[string]interface{}{"image": imageRef, "allTags": allTags})
+		notify := progressNotifier(ctx, srv, request)
+		var total int64
+		push, err := api.PushImage(ctx, cli, imageRef, allTags, func(progress *api.LayerProgress) {
+			var current int64
+			var done, count int
+			current, total, done, count = progress.Totals()
+			notify(float64(current), float64(total), fmt.Sprintf("pushed %d/%d layers", done, count), false)
+		})
+		if err != nil {
+			return nil, err
+		}
+		notify(float64(total), float64(total), "push complete "+push.Digest, true)
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   push,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterImageTagTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_image_tag",
+		mcp.WithDescription("Add references to an image - equivalent to 'docker tag <source> <target>' - References are validated and normalized (a missing tag becomes latest). With removeSource the source tag is removed afterwards, promoting a build in one step, e.g. app:rc -> app:1.4.0"),
+		mcp.WithString("source",
+			mcp.Required(),
+			mcp.Description("Source image ID or reference, e.g. app:rc")),
+		mcp.WithArray("targets",
+			mcp.Required(),
+			mcp.Items(map[string]any{"type": "string"}),
+			mcp.Description("New references, e.g. [\"app:1.4.0\", \"registry.example.com/team/app:1.4.0\"]")),
+		mcp.WithBoolean("removeSource",
+			mcp.DefaultBool(false),
+			mcp.Description("Remove the source tag after tagging (retag). The image itself is kept")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		source, ok := request.Params.Arguments["source"].(string)
+		if !ok || source == "" {
+			return nil, errors.New("source parameter is required and must be a string")
+		}
+		var targets []string
+		if val, ok := request.Params.Arguments["targets"].([]any); ok {
+			for _, target := range val {
+				if str, ok := target.(string); ok && str != "" {
+					targets = append(targets, str)
+				}
+			}
+		}
+		removeSource, _ := request.Params.Arguments["removeSource"].(bool)
+		logs.InfoWithFields("mcp_docker_image_tag called", map[string]interface{}{"source": source, "targets": targets, "removeSource": removeSource})
+		tagged, err := api.TagImage(ctx, cli, source, targets, removeSource)
+		// 中途失败时返回已经添加的标签
+		if err != nil && tagged == nil {
+			return nil, err
+		}
+		payload := map[string]interface{}{
+			"status": "success",
+			"data":   tagged,
+		}
+		if err != nil {
+			payload["status"] = "failed"
+			if len(tagged.Added) > 0 {
+				payload["status"] = "partial"
+			}
+			payload["error"] = err.Error()
+		}
+		result, _ := json.Marshal(payload)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+			IsError: err != nil,
+		}, nil
+	})
+}
+
+func RegisterImageUntagTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_image_untag",
+		mcp.WithDescription("Remove a tag from an image - equivalent to 'docker rmi <repository:tag>' without force - Only the reference is removed while other tags exist; the image is deleted when it was the last reference and no container uses it"),
+		mcp.WithString("image",
+			mcp.Required(),
+			mcp.Description("Image reference to remove, e.g. app:rc")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ref, ok := request.Params.Arguments["image"].(string)
+		if !ok || ref == "" {
+			return nil, errors.New("image parameter is required and must be a string")
+		}
+		logs.InfoWithFields("mcp_docker_image_untag called", map[string]interface{}{"image": ref})
+		removed, err := api.UntagImage(ctx, cli, ref)
+		if err != nil {
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status":  "success",
+			"removed": removed,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterImageHistoryTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_image_history",
+		mcp.WithDescription("Show the layer history of an image - equivalent to 'docker history <image>' - Returns each layer's creating instruction, size and age, the largest contributors, and a breakdown of base image layers versus layers added on top. Useful to answer why an image is so large"),
+		mcp.WithString("image",
+			mcp.Required(),
+			mcp.Description("Image ID or reference")),
+		mcp.WithString("base",
+			mcp.Description("Base image reference, e.g. python:3.12-slim. Defaults to the closest tagged ancestor available locally")),
+		mcp.WithString("sort",
+			mcp.DefaultString("created"),
+			mcp.Enum("created", "size"),
+			mcp.Description("Layer order: created (newest first, like docker history) or size (largest first)")),
+		mcp.WithNumber("largest",
+			mcp.DefaultNumber(api.DefaultLargestLayers),
+			mcp.Min(1),
+			mcp.Description("Number of largest layers to highlight (default 5)")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		imageRef, ok := request.Params.Arguments["image"].(string)
+		if !ok || imageRef == "" {
+			return nil, errors.New("image parameter is required and must be a string")
+		}
+		opts := api.HistoryOptions{Largest: api.DefaultLargestLayers}
+		if val, ok := request.Params.Arguments["base"].(string); ok {
+			opts.Base = val
+		}
+		if val, ok := request.Params.Arguments["sort"].(string); ok {
+			opts.Sort = val
+		}
+		if val, ok := request.Params.Arguments["largest"].(float64); ok {
+			opts.Largest = int(val)
+		}
+		logs.InfoWithFields("mcp_docker_image_history called", map[string]interface{}{"image": imageRef, "base": opts.Base, "sort": opts.Sort})
+		history, err := api.ImageHistory(ctx, cli, imageRef, opts)
+		if err != nil {
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   history,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterImageSearchTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_image_search",
+		mcp.WithDescription("Search a registry for images - equivalent to 'docker search <term>' - Searches Docker Hub by default, or the registry named in the term, e.g. registry.example.com/app"),
+		mcp.WithString("term",
+			mcp.Required(),
+			mcp.Description("Search term, e.g. postgres")),
+		mcp.WithNumber("limit",
+			mcp.Min(1),
+			mcp.Max(100),
+			mcp.Description("Maximum number of results (default 25)")),
+		mcp.WithBoolean("official",
+			mcp.Description("Only official images when true, only non-official when false")),
+		mcp.WithNumber("stars",
+			mcp.Min(0),
+			mcp.Description("Only images with at least this many stars")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var opts api.ImageSearchOptions
+		if err := bindArguments(request, &opts); err != nil {
+			return nil, err
+		}
+		if opts.Term == "" {
+			return nil, errors.New("term parameter is required and must be a string")
+		}
+		logs.InfoObjects("mcp_docker_image_search called", "options", opts)
+		results, err := api.SearchImages(ctx, cli, opts)
+		if err != nil {
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   results,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterImageRemoteTagsTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_image_remote_tags",
+		mcp.WithDescription("List the tags of a remote repository through the registry v2 API, using the credentials stored by mcp_docker_auth_registry - no docker CLI equivalent - Tags are sorted newest version first. When the image includes a tag, the current manifest of that tag is returned too, which answers questions like 'is there a newer tag than postgres:16?'. truncated is true when the repository has more tag pages than the server reads; the result then covers only the pages read"),
+		mcp.WithString("image",
+			mcp.Required(),
+			mcp.Description("Repository with optional tag, e.g. postgres, postgres:16 or registry.example.com/team/app")),
+		mcp.WithString("filter",
+			mcp.Description("Only tags matching this glob pattern, e.g. 16* or *-alpine")),
+		mcp.WithString("sort",
+			mcp.DefaultString("version"),
+			mcp.Enum("version", "name"),
+			mcp.Description("version sorts numerically, newest first (16.10 before 16.9); name sorts alphabetically")),
+		mcp.WithNumber("limit",
+			mcp.Min(0),
+			mcp.Description("Return at most this many tags after sorting")),
+		mcp.WithBoolean("manifests",
+			mcp.DefaultBool(false),
+			mcp.Description("Also fetch digest, size and platforms of each returned tag, at most 20 tags")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var opts api.RemoteTagsOptions
+		if err := bindArguments(request, &opts); err != nil {
+			return nil, err
+		}
+		if opts.Image == "" {
+			return nil, errors.New("image parameter is required and must be a string")
+		}
+		logs.InfoObjects("mcp_docker_image_remote_tags called", "options", opts)
+		tags, err := api.ListRemoteTags(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   tags,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
@@ -0,0 +1,256 @@
+package tool
+
+import (
+	"context"
+	"docker-mcp/cmd/logs"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"time"
+)
+
+// defaultStopTimeout stop 和 restart 默认等待的秒数
+const defaultStopTimeout = 5
+
+func RegisterContainerLifecycleTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	RegisterContainerPauseTool(ctx, srv, cli)
+	RegisterContainerUnpauseTool(ctx, srv, cli)
+	RegisterContainerKillTool(ctx, srv, cli)
+	RegisterContainerRenameTool(ctx, srv, cli)
+	RegisterContainerWaitTool(ctx, srv, cli)
+}
+
+func stopTimeoutOption() mcp.ToolOption {
+	return mcp.WithNumber("timeout",
+		mcp.DefaultNumber(defaultStopTimeout),
+		mcp.Description("Seconds to wait for the container to stop before killing it (default 5, -1 waits indefinitely)"))
+}
+
+func stopSignalOption() mcp.ToolOption {
+	return mcp.WithString("signal",
+		mcp.Description("Signal sent to stop the container, e.g. SIGINT or SIGQUIT. Defaults to the container's StopSignal (usually SIGTERM)"))
+}
+
+// stopOptionsFromRequest 读取 stop 和 restart 共用的 timeout、signal 参数
+func stopOptionsFromRequest(request mcp.CallToolRequest) container.StopOptions {
+	timeout := defaultStopTimeout
+	if val, ok := request.Params.Arguments["timeout"].(float64); ok {
+		timeout = int(val)
+	}
+	options := container.StopOptions{Timeout: &timeout}
+	if val, ok := request.Params.Arguments["signal"].(string); ok {
+		options.Signal = val
+	}
+	return options
+}
+
+func RegisterContainerPauseTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_pause",
+		mcp.WithDescription("Pause all processes in a container - equivalent to 'docker pause <container-id>' - Freezes the container without stopping it"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		logs.InfoWithFields("mcp_docker_container_pause called", map[string]interface{}{"id": id})
+		if err := cli.ContainerPause(ctx, id); err != nil {
+			logs.ErrorWithFields("ContainerPause failed", map[string]interface{}{"id": id, "error": err})
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]string{
+			"status": "success",
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterContainerUnpauseTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_unpause",
+		mcp.WithDescription("Unpause a paused container - equivalent to 'docker unpause <container-id>' - Resumes all processes in the container"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		logs.InfoWithFields("mcp_docker_container_unpause called", map[string]interface{}{"id": id})
+		if err := cli.ContainerUnpause(ctx, id); err != nil {
+			logs.ErrorWithFields("ContainerUnpause failed", map[string]interface{}{"id": id, "error": err})
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]string{
+			"status": "success",
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterContainerKillTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_kill",
+		mcp.WithDescription("Send a signal to a container - equivalent to 'docker kill --signal <signal> <container-id>' - Kills the container with SIGKILL by default, or delivers any other signal such as SIGHUP to reload configuration"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+		mcp.WithString("signal",
+			mcp.DefaultString("SIGKILL"),
+			mcp.Description("Signal name or number, e.g. SIGKILL, SIGHUP, SIGUSR1 or 9")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		signal := "SIGKILL"
+		if val, ok := request.Params.Arguments["signal"].(string); ok && val != "" {
+			signal = val
+		}
+		logs.InfoWithFields("mcp_docker_container_kill called", map[string]interface{}{"id": id, "signal": signal})
+		if err := cli.ContainerKill(ctx, id, signal); err != nil {
+			logs.ErrorWithFields("ContainerKill failed", map[string]interface{}{"id": id, "signal": signal, "error": err})
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]string{
+			"status": "success",
+			"signal": signal,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterContainerRenameTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_rename",
+		mcp.WithDescription("Rename a container - equivalent to 'docker rename <container> <new-name>'"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("New container name")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		name, ok := request.Params.Arguments["name"].(string)
+		if !ok || name == "" {
+			return nil, errors.New("name parameter is required and must be a string")
+		}
+		logs.InfoWithFields("mcp_docker_container_rename called", map[string]interface{}{"id": id, "name": name})
+		if err := cli.ContainerRename(ctx, id, name); err != nil {
+			logs.ErrorWithFields("ContainerRename failed", map[string]interface{}{"id": id, "name": name, "error": err})
+			return nil, err
+		}
+		result, _ := json.Marshal(map[string]string{
+			"status": "success",
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
+
+func RegisterContainerWaitTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+	tool := mcp.NewTool("mcp_docker_container_wait",
+		mcp.WithDescription("Block until a container stops - equivalent to 'docker wait <container-id>' - Returns the exit code, or a timeout status if the condition is not met in time"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Container ID or container name")),
+		mcp.WithString("condition",
+			mcp.DefaultString(string(container.WaitConditionNotRunning)),
+			mcp.Enum(string(container.WaitConditionNotRunning), string(container.WaitConditionNextExit), string(container.WaitConditionRemoved)),
+			mcp.Description("not-running returns immediately for stopped containers, next-exit waits for the next exit, removed waits until the container is removed")),
+		mcp.WithNumber("timeout",
+			mcp.DefaultNumber(60),
+			mcp.Min(0),
+			mcp.Description("Maximum seconds to wait (default 60, 0 waits until the request is cancelled)")),
+	)
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, ok := request.Params.Arguments["id"].(string)
+		if !ok || id == "" {
+			return nil, errors.New("id parameter is required and must be a string")
+		}
+		condition := container.WaitConditionNotRunning
+		if val, ok := request.Params.Arguments["condition"].(string); ok && val != "" {
+			condition = container.WaitCondition(val)
+		}
+		timeout := 60 * time.Second
+		if val, ok := request.Params.Arguments["timeout"].(float64); ok {
+			timeout = time.Duration(val * float64(time.Second))
+		}
+		logs.InfoWithFields("mcp_docker_container_wait called", map[string]interface{}{"id": id, "condition": condition, "timeout": timeout.String()})
+		waitCtx := ctx
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			waitCtx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		waitCh, errCh := cli.ContainerWait(waitCtx, id, condition)
+		data := map[string]interface{}{"condition": condition}
+		select {
+		case res := <-waitCh:
+			data["status"] = "exited"
+			data["exitCode"] = res.StatusCode
+			if res.Error != nil && res.Error.Message != "" {
+				data["error"] = res.Error.Message
+			}
+		case err := <-errCh:
+			// 超时不算失败，返回 timeout 状态让调用方决定是否继续等待
+			if waitCtx.Err() == context.DeadlineExceeded {
+				data["status"] = "timeout"
+				break
+			}
+			logs.ErrorWithFields("ContainerWait failed", map[string]interface{}{"id": id, "error": err})
+			return nil, fmt.Errorf("wait for container %s failed: %v", id, err)
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data":   data,
+		})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(result),
+					Type: "text",
+				},
+			},
+		}, nil
+	})
+}
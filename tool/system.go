@@ -17,6 +17,7 @@ func RegisterSystemTool(ctx context.Context, srv *server.MCPServer, cli *client.
 	RegisterInfoTool(ctx, srv, cli)
 	RegisterServiceVersionTool(ctx, srv, cli)
 	RegisterDiskUsageTool(ctx, srv, cli)
+	RegisterEventsTool(ctx, srv, cli)
 }
 
 func RegisterInfoTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
@@ -2,17 +2,84 @@ package tool
 
 import (
 	"context"
+	"docker-mcp/cmd"
 	"docker-mcp/cmd/logs"
+	"encoding/json"
+	"fmt"
 	"github.com/docker/docker/client"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"time"
 )
 
-func RegisterTool(ctx context.Context, srv *server.MCPServer, cli *client.Client) {
+func RegisterTool(ctx context.Context, srv *server.MCPServer, cli *client.Client, cfg *cmd.Config) {
 	logs.Info("RegisterTool called")
 	RegisterSystemTool(ctx, srv, cli)
 	RegisterContainerTool(ctx, srv, cli)
-	RegisterImageTool(ctx, srv, cli)
+	RegisterImageTool(ctx, srv, cli, cfg.ArchiveDir)
 	RegisterAuthTool(ctx, srv, cli)
 	RegisterVolumeTool(ctx, srv, cli)
 	RegisterNetworkTool(ctx, srv, cli)
+	RegisterArchiveTool(ctx, srv, cli, cfg.ArchiveDir)
+}
+
+// bindArguments 把工具参数解码到带 JSON 标签的结构体，用于数组、对象等结构化参数
+func bindArguments(request mcp.CallToolRequest, v interface{}) error {
+	raw, err := json.Marshal(request.Params.Arguments)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid arguments: %v", err)
+	}
+	return nil
+}
+
+// batchStatus 批量操作的整体状态：全部成功 success，全部失败 failed，其余 partial
+func batchStatus(succeeded, total int) string {
+	switch {
+	case succeeded == total:
+		return "success"
+	case succeeded == 0:
+		return "failed"
+	}
+	return "partial"
+}
+
+// progressInterval 两次进度通知之间的最小间隔
+const progressInterval = 500 * time.Millisecond
+
+// progressNotifier 客户端在请求中带有 progressToken 时，通过 notifications/progress 推送进度，否则返回的函数什么也不做
+func progressNotifier(ctx context.Context, srv *server.MCPServer, request mcp.CallToolRequest) func(progress, total float64, message string, final bool) {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return func(float64, float64, string, bool) {}
+	}
+	token := request.Params.Meta.ProgressToken
+	var last time.Time
+	var sent float64
+	return func(progress, total float64, message string, final bool) {
+		if !final && time.Since(last) < progressInterval {
+			return
+		}
+		last = time.Now()
+		// MCP 要求进度单调递增：新出现的层会让总量变大，但已发送的进度不能回退
+		if progress < sent {
+			progress = sent
+		}
+		if total > 0 && total < progress {
+			total = progress
+		}
+		sent = progress
+		params := map[string]any{
+			"progressToken": token,
+			"progress":      progress,
+			"message":       message,
+		}
+		if total > 0 {
+			params["total"] = total
+		}
+		if err := srv.SendNotificationToClient(ctx, "notifications/progress", params); err != nil {
+			logs.DebugWithFields("Send progress notification failed", map[string]interface{}{"error": err})
+		}
+	}
 }